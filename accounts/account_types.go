@@ -3,35 +3,36 @@ package accounts
 import (
 	client "github.com/banjoh/fake-api-client"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 type Attributes struct {
-	Country                 string   `json:"country,omitempty"`
-	BaseCurrency            string   `json:"base_currency,omitempty"`
-	AccountNumber           string   `json:"account_number,omitempty"`
-	BankID                  string   `json:"bank_id,omitempty"`
-	BankIDCode              string   `json:"bank_id_code,omitempty"`
-	BIC                     string   `json:"bic,omitempty"`
-	IBAN                    string   `json:"iban,omitempty"`
-	CustomerID              string   `json:"customer_id,omitempty"`
-	Name                    []string `json:"name,omitempty"`
-	AlternativeNames        []string `json:"alternative_names,omitempty"`
-	AccountClassification   string   `json:"account_classification,omitempty"`
-	JointAccount            *bool    `json:"joint_account,omitempty"`
-	AccountMatchingOptOut   *bool    `json:"account_matching_opt_out,omitempty"`
-	SecondaryIdentification string   `json:"secondary_identification,omitempty"`
-	Switched                *bool    `json:"switched,omitempty"`
-	Status                  string   `json:"status,omitempty"`
+	Country                 string                `json:"country,omitempty"`
+	BaseCurrency            string                `json:"base_currency,omitempty"`
+	AccountNumber           string                `json:"account_number,omitempty"`
+	BankID                  string                `json:"bank_id,omitempty"`
+	BankIDCode              BankIDCode            `json:"bank_id_code,omitempty"`
+	BIC                     string                `json:"bic,omitempty"`
+	IBAN                    string                `json:"iban,omitempty"`
+	CustomerID              string                `json:"customer_id,omitempty"`
+	Name                    []string              `json:"name,omitempty"`
+	AlternativeNames        []string              `json:"alternative_names,omitempty"`
+	AccountClassification   AccountClassification `json:"account_classification,omitempty"`
+	JointAccount            *bool                 `json:"joint_account,omitempty"`
+	AccountMatchingOptOut   *bool                 `json:"account_matching_opt_out,omitempty"`
+	SecondaryIdentification string                `json:"secondary_identification,omitempty"`
+	Switched                *bool                 `json:"switched,omitempty"`
+	Status                  AccountStatus         `json:"status,omitempty"`
 }
 
 type Account struct {
-	Type           string      `json:"type,omitempty"`
-	ID             *uuid.UUID  `json:"id,omitempty"`
-	Version        *int        `json:"version,omitempty"`
-	OrganisationID *uuid.UUID  `json:"organisation_id,omitempty"`
-	Attributes     *Attributes `json:"attributes,omitempty"`
-	CreatedOn      string      `json:"created_on,omitempty"` // DEBT: Parse string to time struct
-	ModifiedOn     string      `json:"modified_on,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	ID             *uuid.UUID        `json:"id,omitempty"`
+	Version        *int              `json:"version,omitempty"`
+	OrganisationID *uuid.UUID        `json:"organisation_id,omitempty"`
+	Attributes     *Attributes       `json:"attributes,omitempty"`
+	CreatedOn      *client.Timestamp `json:"created_on,omitempty"`
+	ModifiedOn     *client.Timestamp `json:"modified_on,omitempty"`
 }
 
 type AccountDTO struct {
@@ -50,8 +51,52 @@ type AccountCreateDTO struct {
 	Data AccountCreate `json:"data"`
 }
 
+// AccountUpdate holds the changes to apply to an existing account.
+// Only the attributes that are set are sent, the others are left untouched
+type AccountUpdate struct {
+	Attributes *Attributes `json:"attributes,omitempty"`
+}
+
+// Links holds the JSON:API pagination links returned when listing resources.
+// Links are relative to the API base URL and empty when not applicable
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+type AccountListDTO struct {
+	Data  []Account `json:"data"`
+	Links Links     `json:"links"`
+}
+
+// ListOptions controls which page of accounts is returned by List.
+// Zero values are not sent, leaving the server to apply its defaults
+type ListOptions struct {
+	PageNumber int
+	PageSize   int
+	Filter     *Filter
+}
+
+// AccountPage is a single page of accounts returned by List
+type AccountPage struct {
+	Accounts []Account
+	Links    Links
+}
+
+// HasNext reports whether the server advertised a page after this one
+func (p *AccountPage) HasNext() bool {
+	return p != nil && p.Links.Next != ""
+}
+
 type Resource struct {
 	BaseURL      string
 	client       client.HTTPClient
 	retrySleeper client.RetrySleeper
+	retryPolicy  client.RetryPolicy
+	logger       logrus.FieldLogger
+	userAgent    string
+	strictEnums  bool
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"testing"
+	"time"
 
+	client "github.com/banjoh/fake-api-client"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,7 +51,7 @@ func fixtures() map[string]struct {
 				ID:             &id,
 				Version:        &version,
 				OrganisationID: &oID,
-				ModifiedOn:     "2021-05-23T16:05:52.970Z",
+				ModifiedOn:     client.NewTimestamp(time.Date(2021, 5, 23, 16, 5, 52, 970000000, time.UTC)),
 				Attributes: &Attributes{
 					Country:       "GB",
 					BaseCurrency:  "GBP",
@@ -114,3 +116,22 @@ func TestUnmarshalAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalAccountMalformedTimestamp(t *testing.T) {
+	tests := map[string]string{
+		"created on date only":   `{"data": {"created_on": "2021-05-25"}}`,
+		"created on no timezone": `{"data": {"created_on": "2021-05-25T04:29:11.898"}}`,
+		"modified on not a date": `{"data": {"modified_on": "yesterday"}}`,
+		"modified on number":     `{"data": {"modified_on": 1621916951}}`,
+	}
+
+	for name, data := range tests {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			var got AccountDTO
+			err := json.Unmarshal([]byte(data), &got)
+
+			assert.Error(t, err)
+		})
+	}
+}
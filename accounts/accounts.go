@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math/rand"
-	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	client "github.com/banjoh/fake-api-client"
@@ -17,49 +20,50 @@ import (
 )
 
 const (
-	defultBaseURL         = "http://localhost:8080"
-	accountsPath          = "v1/organisation/accounts"
-	defaultContentType    = "application/vnd.api+json"
-	defaultRetrySleepSecs = 2
-	defaultRetryCount     = 5
+	defultBaseURL      = "http://localhost:8080"
+	accountsPath       = "v1/organisation/accounts"
+	defaultContentType = "application/vnd.api+json"
+	defaultUserAgent   = "fake-api-client"
 )
 
-// RetryCount denotes the number of times to retry requests
-// When RetryCount == 0, requests are not retried
-var RetryCount = defaultRetryCount
-
-// RetryDurationSecs is the number of seconds to sleep.
-// A random jitter is added to each sleep interval
-var RetryDurationSecs float64 = defaultRetrySleepSecs
+// ErrNoNextPage is returned by NextPage when the given page is the last one
+var ErrNoNextPage = errors.New("accounts: no next page")
 
 // New creates a new instance of the accounts resource API
-// This client utilizes a default http client
-func New() (*Resource, error) {
-	return NewWithClient(client.DefaultClient, &client.DefaultRetrySleeper{})
+// configured by the given options. Without options the client utilizes
+// the default http client against the default base URL
+func New(opts ...Option) (*Resource, error) {
+	r := &Resource{
+		BaseURL:      defultBaseURL,
+		client:       client.DefaultClient,
+		retrySleeper: &client.DefaultRetrySleeper{},
+		retryPolicy:  client.DefaultRetryPolicy(),
+		logger:       logrus.StandardLogger(),
+		userAgent:    defaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, fmt.Errorf("accounts.New: %w", err)
+		}
+	}
+
+	return r, nil
 }
 
 // NewWithClient creates a new instance of the accounts resource API
 // This client requires a dependency injected http client and retry sleeper
-func NewWithClient(c client.HTTPClient, s client.RetrySleeper) (*Resource, error) {
-	if c == nil {
-		return nil, fmt.Errorf("accounts.NewWithClient: nil client.HTTPClient")
-	}
-	if s == nil {
-		return nil, fmt.Errorf("accounts.NewWithClient: nil client.RetrySleeper")
-	}
-
-	// DEBT: The base URL needs to be exposed so as to override it
-	return &Resource{
-		BaseURL:      defultBaseURL,
-		client:       c,
-		retrySleeper: s,
-	}, nil
+func NewWithClient(c client.HTTPClient, s client.RetrySleeper, opts ...Option) (*Resource, error) {
+	return New(append([]Option{WithHTTPClient(c), WithRetrySleeper(s)}, opts...)...)
 }
 
 // Create an account resource
-// This API is not idempotent and will therefore not be retried when errors occur.
+// Every request carries a freshly generated Idempotency-Key header which makes it
+// safe to retry, see CreateWithIdempotencyKey. The account is validated first,
+// invalid accounts are not sent.
 // * On success, an *Account is returns an the error will be nil
 // * On failure, the returned *Account will be nil. The error variable will contain
+//   * *ValidationError if the account failed validation, see AccountCreate.Validate
 //   * client.APIError if the response contained API specific errors
 //	 * any other error that occured. This includes json marshaling errors,
 //	   network specific errors etc
@@ -68,15 +72,52 @@ func (r *Resource) Create(ctx context.Context, acc *AccountCreate) (*Account, er
 		return nil, fmt.Errorf("accounts.Create: nil Context")
 	}
 
+	return r.CreateWithIdempotencyKey(ctx, acc, uuid.New().String())
+}
+
+// CreateWithIdempotencyKey creates an account resource, sending the given key in the
+// Idempotency-Key header. Reusing the key of a previous call, e.g. after a crash,
+// lets the server recognise the request as a duplicate rather than creating the
+// account twice. The key makes the request idempotent so it is retried when some
+// specific errors occur.
+// Should a retry be rejected with 409 Conflict, the account with the requested ID
+// is fetched: a previous attempt may have created it before its response was lost.
+// The existing account is returned when it matches the requested one.
+// Errors are otherwise reported the same way as Create
+func (r *Resource) CreateWithIdempotencyKey(ctx context.Context, acc *AccountCreate, key string) (*Account, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("accounts.CreateWithIdempotencyKey: nil Context")
+	}
+
+	created, _, err := r.create(ctx, acc, key)
+	return created, err
+}
+
+// create implements CreateWithIdempotencyKey. When a retry is rejected with
+// 409 Conflict, the account fetched in response is also returned, whether it
+// matches the requested one or not, so that callers do not fetch it again
+func (r *Resource) create(ctx context.Context, acc *AccountCreate, key string) (*Account, *Account, error) {
 	if acc == nil {
-		return nil, fmt.Errorf("nil AccountCreate")
+		return nil, nil, fmt.Errorf("nil AccountCreate")
+	}
+
+	if err := acc.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.checkEnums(acc.Attributes); err != nil {
+		return nil, nil, err
+	}
+
+	if key == "" {
+		return nil, nil, fmt.Errorf("empty idempotency key")
 	}
 
 	dto := AccountCreateDTO{Data: *acc}
 
 	data, err := json.Marshal(dto)
 	if err != nil {
-		return nil, fmt.Errorf("marshalling error: %w", err)
+		return nil, nil, fmt.Errorf("marshalling error: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/%s", r.BaseURL, accountsPath)
@@ -85,23 +126,93 @@ func (r *Resource) Create(ctx context.Context, acc *AccountCreate) (*Account, er
 	body := bytes.NewReader(data)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	setPostDefaultHeaders(req)
+	r.setPostDefaultHeaders(req)
+	req.Header.Set("Idempotency-Key", key)
 
-	// We only retry idempotent requests i.e GET, DELETE
-	resp, err := r.client.Do(req)
+	resp, attempts, err := r.retriedDoAttempts(req)
 	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+		return nil, nil, fmt.Errorf("request error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusCreated {
-		return unmarshalAccount(resp)
+		created, err := r.unmarshalAccount(resp)
+		return created, nil, err
 	}
 
-	return nil, unmarshalErrorResponse(resp)
+	err = unmarshalErrorResponse(resp)
+	if resp.StatusCode == http.StatusConflict && attempts > 1 && acc.ID != nil {
+		existing, fetchErr := r.Fetch(ctx, *acc.ID)
+		if fetchErr != nil {
+			return nil, nil, err
+		}
+		if len(diffAccount(acc, existing)) == 0 {
+			r.logger.Debugf("Account created by an earlier attempt: id=%s, attempts=%d", acc.ID, attempts)
+			return existing, nil, nil
+		}
+		return nil, existing, err
+	}
+
+	return nil, nil, err
+}
+
+// CreateOrGet creates an account resource, or gets it should it already exist.
+// The requested account must have an ID. When Create fails with 409 Conflict, or
+// with an error leaving it unknown whether the account was created such as a
+// network error, the account with the requested ID is fetched and compared with
+// the requested one.
+// * On success, the account is returned along with whether it was created by this call
+// * When the existing account differs from the requested one, it is returned along
+//   with a *MismatchError listing the differing fields
+// * On failure, the returned *Account will be nil and the error is that of Create
+func (r *Resource) CreateOrGet(ctx context.Context, acc *AccountCreate) (*Account, bool, error) {
+	if ctx == nil {
+		return nil, false, fmt.Errorf("accounts.CreateOrGet: nil Context")
+	}
+
+	if acc == nil || acc.ID == nil {
+		return nil, false, fmt.Errorf("accounts.CreateOrGet: AccountCreate without ID")
+	}
+
+	// Invalid accounts are never sent, there is nothing to reconcile
+	if err := acc.Validate(); err != nil {
+		return nil, false, err
+	}
+	if err := r.checkEnums(acc.Attributes); err != nil {
+		return nil, false, err
+	}
+
+	created, existing, err := r.create(ctx, acc, uuid.New().String())
+	if err == nil {
+		return created, true, nil
+	}
+
+	var apiErr *client.APIError
+	var validationErr *ValidationError
+	isAPIErr := errors.As(err, &apiErr)
+	conflict := isAPIErr && apiErr.StatusCode == http.StatusConflict
+	indeterminate := !isAPIErr && !errors.As(err, &validationErr) && ctx.Err() == nil
+	if !conflict && !indeterminate {
+		return nil, false, err
+	}
+
+	// A conflicting retry has already been answered by fetching the account
+	if existing == nil {
+		var fetchErr error
+		if existing, fetchErr = r.Fetch(ctx, *acc.ID); fetchErr != nil {
+			r.logger.Debugf("Failed fetching account after create failure: id=%s, err=%s", acc.ID, fetchErr)
+			return nil, false, err
+		}
+	}
+
+	if diff := diffAccount(acc, existing); len(diff) > 0 {
+		return existing, false, &MismatchError{ID: *acc.ID, Fields: diff}
+	}
+
+	return existing, false, nil
 }
 
 // Fetch an account resource
@@ -123,21 +234,120 @@ func (r *Resource) Fetch(ctx context.Context, accID uuid.UUID) (*Account, error)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	setDefaultHeaders(req)
+	r.setDefaultHeaders(req)
 
-	resp, err := retriedDo(req, r.client, r.retrySleeper)
+	resp, err := r.retriedDo(req)
 	if err != nil {
 		return nil, fmt.Errorf("request error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
-		return unmarshalAccount(resp)
+		return r.unmarshalAccount(resp)
 	}
 
 	return nil, unmarshalErrorResponse(resp)
 }
 
+// Update an account resource
+// The version must be the current version of the account as last seen by the caller.
+// This API is not idempotent and will therefore not be retried when errors occur.
+// * On success, the updated *Account is returned and the error will be nil
+// * On failure, the returned *Account will be nil. The error variable will contain
+//   * *VersionConflictError if the version is stale, i.e the account was changed meanwhile
+//   * client.APIError if the response contained other API specific errors
+//	 * any other error that occured. This includes json marshaling errors,
+//	   network specific errors etc
+func (r *Resource) Update(ctx context.Context, accID uuid.UUID, version int, patch *AccountUpdate) (*Account, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("accounts.Update: nil Context")
+	}
+
+	if patch == nil {
+		return nil, fmt.Errorf("nil AccountUpdate")
+	}
+
+	if err := r.checkEnums(patch.Attributes); err != nil {
+		return nil, err
+	}
+
+	dto := AccountDTO{Data: Account{
+		Type:       "accounts",
+		ID:         &accID,
+		Version:    &version,
+		Attributes: patch.Attributes,
+	}}
+
+	data, err := json.Marshal(dto)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling error: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/%s", r.BaseURL, accountsPath, accID)
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	r.setPostDefaultHeaders(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return r.unmarshalAccount(resp)
+	}
+
+	err = unmarshalErrorResponse(resp)
+
+	var apiErr *client.APIError
+	if resp.StatusCode == http.StatusConflict && errors.As(err, &apiErr) {
+		return nil, &VersionConflictError{ID: accID, Version: version, Err: apiErr}
+	}
+
+	return nil, err
+}
+
+// UpdateWithRefetch fetches the current version of an account and applies the
+// patch to it. Should the account change between the fetch and the update, it is
+// refetched and the patch re-applied, up to maxAttempts updates in total.
+// The last *VersionConflictError is returned once the attempts run out
+func (r *Resource) UpdateWithRefetch(ctx context.Context, accID uuid.UUID, patch *AccountUpdate,
+	maxAttempts int) (*Account, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("accounts.UpdateWithRefetch: maxAttempts must be positive: %d", maxAttempts)
+	}
+
+	var err error
+	for i := 0; i < maxAttempts; i++ {
+		var current *Account
+		current, err = r.Fetch(ctx, accID)
+		if err != nil {
+			return nil, err
+		}
+
+		if current.Version == nil {
+			return nil, fmt.Errorf("fetched account has no version: id=%s", accID)
+		}
+
+		var acc *Account
+		acc, err = r.Update(ctx, accID, *current.Version, patch)
+
+		var conflict *VersionConflictError
+		if !errors.As(err, &conflict) {
+			return acc, err
+		}
+
+		r.logger.Debugf("Account changed since fetched. Refetching: id=%s, version=%d", accID, *current.Version)
+	}
+
+	return nil, err
+}
+
 // Delete an account resource
 // This API is idempotent and will therefore be retried when some specific errors occur.
 // * On success, the account resource will be deleted and the error will be nil
@@ -159,9 +369,9 @@ func (r *Resource) Delete(ctx context.Context, accID uuid.UUID, version int) err
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	setDefaultHeaders(req)
+	r.setDefaultHeaders(req)
 
-	resp, err := retriedDo(req, r.client, r.retrySleeper)
+	resp, err := r.retriedDo(req)
 	if err != nil {
 		return fmt.Errorf("request error: %w", err)
 	}
@@ -175,7 +385,138 @@ func (r *Resource) Delete(ctx context.Context, accID uuid.UUID, version int) err
 	return unmarshalErrorResponse(resp)
 }
 
-func unmarshalAccount(resp *http.Response) (*Account, error) {
+// List a page of account resources
+// This API is idempotent and will therefore be retried when some specific errors occur.
+// A nil opts lists the first page using the server's default page size.
+// * On success, the page of accounts and its pagination links are returned in *AccountPage
+// * On failure, the returned *AccountPage will be nil. The error variable will contain
+//   * client.APIError if the response contained API specific errors
+//	 * any other error that occured. This includes json marshaling errors,
+//	   network specific errors etc
+func (r *Resource) List(ctx context.Context, opts *ListOptions) (*AccountPage, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("accounts.List: nil Context")
+	}
+
+	endpoint := fmt.Sprintf("%s/%s", r.BaseURL, accountsPath)
+	if q := opts.query(); len(q) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, q.Encode())
+	}
+
+	return r.listPage(ctx, endpoint)
+}
+
+// NextPage fetches the page following the given one using its `next` link.
+// ErrNoNextPage is returned when page is the last page. Errors are otherwise
+// reported the same way as List
+func (r *Resource) NextPage(ctx context.Context, page *AccountPage) (*AccountPage, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("accounts.NextPage: nil Context")
+	}
+
+	if !page.HasNext() {
+		return nil, ErrNoNextPage
+	}
+
+	endpoint, err := r.resolveLink(page.Links.Next)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.listPage(ctx, endpoint)
+}
+
+func (r *Resource) listPage(ctx context.Context, endpoint string) (*AccountPage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	r.setDefaultHeaders(req)
+
+	resp, err := r.retriedDo(req)
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return r.unmarshalAccountPage(resp)
+	}
+
+	return nil, unmarshalErrorResponse(resp)
+}
+
+// resolveLink turns a pagination link, which the API returns relative
+// to its own root, into an absolute URL against BaseURL. The path of
+// BaseURL, such as the prefix of a gateway, is kept in front of the link's
+func (r *Resource) resolveLink(link string) (string, error) {
+	base, err := url.Parse(r.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base url: %w", err)
+	}
+
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination link: %w", err)
+	}
+	if ref.IsAbs() || ref.Host != "" {
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	prefix := strings.TrimRight(base.EscapedPath(), "/")
+	path := "/" + strings.TrimLeft(ref.EscapedPath(), "/")
+	if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+		path = prefix + path
+	}
+
+	resolved, err := base.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination link: %w", err)
+	}
+	resolved.RawQuery = ref.RawQuery
+	return resolved.String(), nil
+}
+
+func (o *ListOptions) query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+
+	if o.PageNumber > 0 {
+		q.Set("page[number]", strconv.Itoa(o.PageNumber))
+	}
+	if o.PageSize > 0 {
+		q.Set("page[size]", strconv.Itoa(o.PageSize))
+	}
+	o.Filter.encode(q)
+
+	return q
+}
+
+func (r *Resource) unmarshalAccountPage(resp *http.Response) (*AccountPage, error) {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var got AccountListDTO
+	err = json.Unmarshal(b, &got)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling err: %w", err)
+	}
+
+	for _, acc := range got.Data {
+		if err := r.checkEnums(acc.Attributes); err != nil {
+			return nil, fmt.Errorf("unmarshaling err: id=%s: %w", acc.ID, err)
+		}
+	}
+
+	return &AccountPage{Accounts: got.Data, Links: got.Links}, nil
+}
+
+func (r *Resource) unmarshalAccount(resp *http.Response) (*Account, error) {
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
@@ -187,100 +528,167 @@ func unmarshalAccount(resp *http.Response) (*Account, error) {
 		return nil, fmt.Errorf("unmarshaling err: %w", err)
 	}
 
+	if err := r.checkEnums(got.Data.Attributes); err != nil {
+		return nil, fmt.Errorf("unmarshaling err: %w", err)
+	}
+
 	return &got.Data, nil
 }
 
+// checkEnums rejects unknown enum values when the resource is strict about them
+func (r *Resource) checkEnums(attr *Attributes) error {
+	if !r.strictEnums || attr == nil {
+		return nil
+	}
+	return attr.CheckEnums()
+}
+
 func unmarshalErrorResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if string(body) == "" {
-		return &client.APIError{
-			StatusCode: resp.StatusCode,
+	apiErr := &client.APIError{StatusCode: resp.StatusCode}
+	if string(body) != "" {
+		err = json.Unmarshal(body, apiErr)
+		if err != nil {
+			apiErr = &client.APIError{
+				StatusCode:   resp.StatusCode,
+				ErrorMessage: string(body),
+			}
 		}
+		apiErr.StatusCode = resp.StatusCode
 	}
 
-	var apiErr client.APIError
-	err = json.Unmarshal(body, &apiErr)
-	if err != nil {
-		return &client.APIError{
-			StatusCode:   resp.StatusCode,
-			ErrorMessage: string(body),
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &client.RateLimitedError{
+			RateLimit: client.ParseRateLimit(resp.Header, time.Now()),
+			Err:       apiErr,
 		}
 	}
 
-	apiErr.StatusCode = resp.StatusCode
-	return &apiErr
+	return apiErr
 }
 
-func setDefaultHeaders(req *http.Request) {
+func (r *Resource) setDefaultHeaders(req *http.Request) {
 	req.Header.Set("Accept", defaultContentType)
+	req.Header.Set("User-Agent", r.userAgent)
 	ts := time.Now().UTC().Format("2006-01-02T15:04:05.999Z")
 	req.Header.Set("Date", ts)
 }
 
-func setPostDefaultHeaders(req *http.Request) {
+func (r *Resource) setPostDefaultHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", defaultContentType)
 
-	setDefaultHeaders(req)
+	r.setDefaultHeaders(req)
 }
 
+// isTemporaryOrTimeout tells whether a network error is worth retrying:
+// timeouts, temporary errors and connections dropped by the server
 func isTemporaryOrTimeout(err error) bool {
-	if ne, ok := err.(net.Error); ok && (ne.Temporary() || ne.Timeout()) { // nolint: errorlint
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
 		return true
 	}
 
-	return false
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// sleep waits d before the next attempt of a request. Sleepers implementing
+// client.ContextSleeper are cut short when ctx is done, others are checked
+// for ctx only once they return
+func (r *Resource) sleep(ctx context.Context, d time.Duration) error {
+	if s, ok := r.retrySleeper.(client.ContextSleeper); ok {
+		return s.SleepContext(ctx, d)
+	}
+
+	r.retrySleeper.Sleep(d)
+	return ctx.Err()
 }
 
 // retriedDo implements a simple retry logic for temporary error situations
-func retriedDo(req *http.Request, c client.HTTPClient, s client.RetrySleeper) (*http.Response, error) {
-	if RetryCount < 1 || RetryDurationSecs <= 0 {
-		return c.Do(req)
+// as described by the resource's client.RetryPolicy
+func (r *Resource) retriedDo(req *http.Request) (*http.Response, error) {
+	resp, _, err := r.retriedDoAttempts(req)
+	return resp, err
+}
+
+// retriedDoAttempts is retriedDo also reporting how many times the request was sent
+func (r *Resource) retriedDoAttempts(req *http.Request) (*http.Response, int, error) {
+	policy := r.retryPolicy
+	if policy.MaxAttempts < 2 {
+		resp, err := r.client.Do(req)
+		return resp, 1, err
 	}
 
 	var resp *http.Response
 	var err error
+	var delay time.Duration
 
-	for i := 0; i < RetryCount; i++ {
-
-		// sleep + jitter. An additional jitter is necessary so as to
-		// avoid many clients retrying at the exact same time. The many
-		// concurrent requests can exhaust server TCP connection resources
-		duration := (RetryDurationSecs + rand.Float64()) * 1000 // nolint: gosec
-
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
 		if resp != nil {
 			// Close previous response body stream. Not doing so
 			// might lead to socket connection leaks
 			resp.Body.Close()
 		}
 
-		resp, err = c.Do(req)
+		if attempt > 1 && req.GetBody != nil {
+			// The body stream was consumed by the previous attempt
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, attempt - 1, fmt.Errorf("failed to reset request body: %w", bodyErr)
+			}
+			req.Body = body
+		}
+
+		resp, err = r.client.Do(req)
 		if err != nil {
-			// Retry network errors deemed retryable
-			if !isTemporaryOrTimeout(err) {
-				logrus.Debugf("Network error caught. Retry request after %.0fms: err=%s", duration, err)
-				s.Sleep(time.Duration(duration) * time.Millisecond)
-
-				continue
-			} else {
-				return nil, err
+			// Retry network errors deemed retryable, unless the caller gave up
+			if req.Context().Err() != nil || !isTemporaryOrTimeout(err) {
+				return nil, attempt, err
 			}
+		} else if !policy.IsRetryableStatus(resp.StatusCode) {
+			return resp, attempt, err
+		}
+
+		if attempt == policy.MaxAttempts {
+			return resp, attempt, err
 		}
 
-		// Retry API errors safe for retrying
-		switch resp.StatusCode {
-		case 500, 502, 503, 504:
-			logrus.Debugf("Server responded with error. Retry request after %.0fms: code=%d, status=%s",
-				duration, resp.StatusCode, resp.Status,
+		delay = policy.Delay(attempt, delay)
+		if err == nil {
+			// The server knows best when it is ready to take requests again
+			now := time.Now()
+			if wait, ok := client.ParseRateLimit(resp.Header, now).Wait(now); ok {
+				if policy.MaxDelay > 0 && wait > policy.MaxDelay {
+					r.logger.Debugf("Server asked to wait longer than allowed. Not retrying: wait=%s, max=%s",
+						wait, policy.MaxDelay,
+					)
+					return resp, attempt, err
+				}
+				delay = wait
+			}
+		}
+		if err != nil {
+			r.logger.Debugf("Network error caught. Retry request after %s: err=%s", delay, err)
+		} else {
+			r.logger.Debugf("Server responded with error. Retry request after %s: code=%d, status=%s",
+				delay, resp.StatusCode, resp.Status,
 			)
-			s.Sleep(time.Duration(duration) * time.Millisecond)
-		default:
-			return resp, err
+		}
+		if err := r.sleep(req.Context(), delay); err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, attempt, err
 		}
 	}
 
-	return resp, err
+	return resp, policy.MaxAttempts, err
 }
@@ -0,0 +1,223 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrGet(t *testing.T) {
+	id := uuid.New()
+	oID := uuid.New()
+	networkErr := errors.New("connection reset by peer")
+
+	existing := func(country string) string {
+		return fmt.Sprintf(`{"data": {"type": "accounts", "id": "%s", "organisation_id": "%s", "version": 2,
+			"attributes": {"country": "%s", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["John Doe"]}}}`, id, oID, country)
+	}
+
+	tests := map[string]struct {
+		createCode  int
+		createBody  string
+		createErr   error
+		fetchCode   int
+		fetchBody   string
+		wantCreated bool
+		wantAccount bool
+		wantFetch   bool
+		wantErr     error
+		wantFields  []string
+	}{
+		"created": {
+			createCode: http.StatusCreated, createBody: existing("GB"),
+			wantCreated: true, wantAccount: true,
+		},
+		"conflict with matching account": {
+			createCode: http.StatusConflict, createBody: `{"error_message": "duplicate"}`,
+			fetchCode: http.StatusOK, fetchBody: existing("GB"),
+			wantAccount: true, wantFetch: true,
+		},
+		"conflict with differing account": {
+			createCode: http.StatusConflict, createBody: `{"error_message": "duplicate"}`,
+			fetchCode: http.StatusOK, fetchBody: existing("FR"),
+			wantAccount: true, wantFetch: true, wantFields: []string{"attributes.country"},
+		},
+		"network error but account created": {
+			createErr: networkErr,
+			fetchCode: http.StatusOK, fetchBody: existing("GB"),
+			wantAccount: true, wantFetch: true,
+		},
+		"network error and account missing": {
+			createErr: networkErr,
+			fetchCode: http.StatusNotFound,
+			wantFetch: true, wantErr: networkErr,
+		},
+		"validation error": {
+			createCode: http.StatusBadRequest, createBody: `{"error_message": "invalid country"}`,
+			wantErr: &client.APIError{ErrorMessage: "invalid country", StatusCode: http.StatusBadRequest},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			fetched := false
+			mock := client.MockClient{}
+			mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+				if req.Method == "GET" {
+					fetched = true
+					return &http.Response{
+						StatusCode: tc.fetchCode,
+						Body:       io.NopCloser(bytes.NewReader([]byte(tc.fetchBody))),
+					}, nil
+				}
+				if tc.createErr != nil {
+					return nil, tc.createErr
+				}
+				return &http.Response{
+					StatusCode: tc.createCode,
+					Body:       io.NopCloser(bytes.NewReader([]byte(tc.createBody))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithRetry(client.NoRetryPolicy()))
+			require.NoError(t, err)
+
+			accCreate := AccountCreate{
+				Type:           "accounts",
+				ID:             &id,
+				OrganisationID: &oID,
+				Attributes:     validAttributes(),
+			}
+			acc, created, err := accClient.CreateOrGet(context.Background(), &accCreate)
+
+			assert.Equal(t, tc.wantCreated, created)
+			assert.Equal(t, tc.wantFetch, fetched)
+
+			if tc.wantAccount {
+				require.NotNil(t, acc)
+				assert.Equal(t, id, *acc.ID)
+			} else {
+				assert.Nil(t, acc)
+			}
+
+			switch {
+			case tc.wantFields != nil:
+				var mismatch *MismatchError
+				require.True(t, errors.As(err, &mismatch))
+				assert.Equal(t, id, mismatch.ID)
+				assert.Equal(t, tc.wantFields, mismatch.Fields)
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateOrGetConflictAfterRetry(t *testing.T) {
+	id := uuid.New()
+	oID := uuid.New()
+
+	posts, gets := 0, 0
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			gets++
+			body := fmt.Sprintf(`{"data": {"type": "accounts", "id": "%s", "organisation_id": "%s", "version": 0,
+				"attributes": {"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["Jane Doe"]}}}`, id, oID)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+		}
+
+		posts++
+		code := http.StatusServiceUnavailable
+		if posts > 1 {
+			code = http.StatusConflict
+		}
+		return &http.Response{StatusCode: code, Body: io.NopCloser(bytes.NewReader([]byte("")))}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithRetry(client.RetryPolicy{
+		MaxAttempts:          2,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+	require.NoError(t, err)
+
+	accCreate := AccountCreate{Type: "accounts", ID: &id, OrganisationID: &oID, Attributes: validAttributes()}
+	acc, created, err := accClient.CreateOrGet(context.Background(), &accCreate)
+
+	var mismatch *MismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, []string{"attributes.name"}, mismatch.Fields)
+	require.NotNil(t, acc)
+	assert.Equal(t, []string{"Jane Doe"}, acc.Attributes.Name)
+	assert.False(t, created)
+	assert.Equal(t, 2, posts)
+	assert.Equal(t, 1, gets, "the account fetched by Create is reused")
+}
+
+func TestCreateOrGetStrictEnums(t *testing.T) {
+	sent := false
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return nil, errors.New("unexpected request")
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithStrictEnums())
+	require.NoError(t, err)
+
+	accCreate := validAccountCreate()
+	accCreate.Attributes.AccountClassification = "bussiness"
+	acc, created, err := accClient.CreateOrGet(context.Background(), accCreate)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	assert.Equal(t, "attributes.account_classification", validationErr.Problems[0].Field)
+	assert.Nil(t, acc)
+	assert.False(t, created)
+	assert.False(t, sent)
+
+	// An unknown value in the response is no reason to fetch the account
+	fetched := false
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			fetched = true
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body: io.NopCloser(bytes.NewReader([]byte(fmt.Sprintf(
+				`{"data": {"type": "accounts", "id": "%s", "attributes": {"country": "GB", "status": "archived"}}}`, uuid.New(),
+			)))),
+		}, nil
+	}
+
+	_, _, err = accClient.CreateOrGet(context.Background(), validAccountCreate())
+
+	require.True(t, errors.As(err, &validationErr), err)
+	assert.Equal(t, "attributes.status", validationErr.Problems[0].Field)
+	assert.False(t, fetched)
+}
+
+func TestCreateOrGetRequiresID(t *testing.T) {
+	accClient, err := NewWithClient(&client.MockClient{}, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, created, err := accClient.CreateOrGet(context.Background(), &AccountCreate{Type: "accounts"})
+	assert.Error(t, err)
+	assert.Nil(t, acc)
+	assert.False(t, created)
+
+	_, _, err = accClient.CreateOrGet(context.Background(), nil)
+	assert.Error(t, err)
+}
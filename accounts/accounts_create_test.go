@@ -3,10 +3,13 @@ package accounts
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	client "github.com/banjoh/fake-api-client"
 	"github.com/google/uuid"
@@ -48,10 +51,7 @@ func TestCreateAccountSuccess(t *testing.T) {
 		Type:           "accounts",
 		ID:             &id,
 		OrganisationID: &oID,
-		Attributes: &Attributes{
-			Country: "GB",
-			Name:    []string{"John Doe"},
-		},
+		Attributes:     validAttributes(),
 	}
 
 	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
@@ -68,8 +68,8 @@ func TestCreateAccountSuccess(t *testing.T) {
 	assert.Equal(t, "accounts", acc.Type)
 	assert.Equal(t, "GB", acc.Attributes.Country)
 	assert.Equal(t, []string{"John Doe"}, acc.Attributes.Name)
-	assert.Equal(t, "2021-05-25T04:29:11.898Z", acc.CreatedOn)
-	assert.Equal(t, "2021-05-25T04:29:11.898Z", acc.ModifiedOn)
+	assert.Equal(t, time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC), acc.CreatedOn.Time)
+	assert.Equal(t, time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC), acc.ModifiedOn.Time)
 }
 
 func TestCreateAccountErrors(t *testing.T) {
@@ -109,9 +109,8 @@ func TestCreateAccountErrors(t *testing.T) {
 			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
 			require.NoError(t, err)
 
-			accCreate := AccountCreate{}
 			ctx := context.Background()
-			acc, err := accClient.Create(ctx, &accCreate)
+			acc, err := accClient.Create(ctx, validAccountCreate())
 
 			assert.ErrorIs(t, err, tc.err)
 			assert.Nil(t, acc)
@@ -129,3 +128,177 @@ func TestCreateAccountNilAccountCreate(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, acc)
 }
+
+func TestCreateAccountIdempotencyKey(t *testing.T) {
+	var keys []string
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"data": {"type": "accounts"}}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = accClient.Create(ctx, validAccountCreate())
+	require.NoError(t, err)
+	_, err = accClient.Create(ctx, validAccountCreate())
+	require.NoError(t, err)
+	_, err = accClient.CreateWithIdempotencyKey(ctx, validAccountCreate(), "my-key")
+	require.NoError(t, err)
+
+	require.Len(t, keys, 3)
+	_, err = uuid.Parse(keys[0])
+	assert.NoError(t, err, "generated keys are uuids")
+	assert.NotEqual(t, keys[0], keys[1], "generated keys are unique")
+	assert.Equal(t, "my-key", keys[2])
+
+	_, err = accClient.CreateWithIdempotencyKey(ctx, validAccountCreate(), "")
+	assert.Error(t, err)
+}
+
+func TestCreateAccountRetriedWithSameKeyAndBody(t *testing.T) {
+	var keys, bodies []string
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		bodies = append(bodies, string(body))
+
+		if len(keys) < 3 {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	accCreate := validAccountCreate()
+	acc, err := accClient.Create(context.Background(), accCreate)
+
+	require.NoError(t, err)
+	assert.Equal(t, "accounts", acc.Type)
+	require.Len(t, keys, 3)
+	assert.Equal(t, []string{keys[0], keys[0], keys[0]}, keys)
+	assert.Equal(t, []string{bodies[0], bodies[0], bodies[0]}, bodies)
+	want, err := json.Marshal(AccountCreateDTO{Data: *accCreate})
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), bodies[0])
+}
+
+func TestCreateAccountConflictAfterRetry(t *testing.T) {
+	id := uuid.New()
+	oID := uuid.New()
+
+	tests := map[string]struct {
+		existingCountry string
+		failures        int
+		fetched         bool
+		created         bool
+	}{
+		"earlier attempt created the account": {
+			existingCountry: "GB", failures: 1, fetched: true, created: true,
+		},
+		"existing account differs": {
+			existingCountry: "FR", failures: 1, fetched: true,
+		},
+		"conflict on first attempt": {
+			existingCountry: "GB", failures: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			posts, fetches := 0, 0
+			mock := client.MockClient{}
+			mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+				if req.Method == "GET" {
+					fetches++
+					body := fmt.Sprintf(`{"data": {"type": "accounts", "id": "%s", "organisation_id": "%s",
+						"version": 0, "attributes": {"country": "%s", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["John Doe"], "status": "confirmed"}}}`,
+						id, oID, tc.existingCountry)
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+					}, nil
+				}
+
+				posts++
+				if posts <= tc.failures {
+					return &http.Response{
+						StatusCode: http.StatusBadGateway,
+						Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusConflict,
+					Body: io.NopCloser(bytes.NewReader([]byte(
+						`{"error_message": "Account cannot be created as it violates a duplicate constraint"}`))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+			require.NoError(t, err)
+
+			accCreate := AccountCreate{
+				Type:           "accounts",
+				ID:             &id,
+				OrganisationID: &oID,
+				Attributes:     validAttributes(),
+			}
+			acc, err := accClient.Create(context.Background(), &accCreate)
+
+			if tc.created {
+				require.NoError(t, err)
+				assert.Equal(t, id, *acc.ID)
+				assert.Equal(t, StatusConfirmed, acc.Attributes.Status)
+			} else {
+				assert.ErrorIs(t, err, &client.APIError{
+					ErrorMessage: "Account cannot be created as it violates a duplicate constraint",
+					StatusCode:   http.StatusConflict,
+				})
+				assert.Nil(t, acc)
+			}
+
+			if tc.fetched {
+				assert.Equal(t, 1, fetches)
+			} else {
+				assert.Equal(t, 0, fetches)
+			}
+		})
+	}
+}
+
+func TestCreateAccountInvalid(t *testing.T) {
+	sent := false
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		sent = true
+		return nil, errors.New("unexpected request")
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, err := accClient.Create(context.Background(), &AccountCreate{})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"type", "id", "organisation_id", "attributes"}, validationErr.Fields())
+	assert.Nil(t, acc)
+	assert.False(t, sent, "invalid accounts are not sent")
+}
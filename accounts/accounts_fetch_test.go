@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	client "github.com/banjoh/fake-api-client"
 	"github.com/google/uuid"
@@ -57,8 +58,8 @@ func TestFetchAccountSuccess(t *testing.T) {
 	assert.Equal(t, "accounts", acc.Type)
 	assert.Equal(t, "GB", acc.Attributes.Country)
 	assert.Equal(t, []string{"John Doe"}, acc.Attributes.Name)
-	assert.Equal(t, "2021-05-25T04:29:11.898Z", acc.CreatedOn)
-	assert.Equal(t, "2021-05-25T04:29:11.898Z", acc.ModifiedOn)
+	assert.Equal(t, time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC), acc.CreatedOn.Time)
+	assert.Equal(t, time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC), acc.ModifiedOn.Time)
 }
 
 func TestFetchAccountErrors(t *testing.T) {
@@ -102,3 +103,22 @@ func TestFetchAccountErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchAccountMalformedTimestamp(t *testing.T) {
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"data": {"type": "accounts", "created_on": "25/05/2021 04:29"}}`)),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, err := accClient.Fetch(context.Background(), uuid.New())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timestamp")
+	assert.Nil(t, acc)
+}
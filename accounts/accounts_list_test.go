@@ -0,0 +1,211 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountsSuccess(t *testing.T) {
+	id1 := uuid.New()
+	id2 := uuid.New()
+
+	json := fmt.Sprintf(`{
+		"data": [
+		  {"type": "accounts", "id": "%s", "version": 0},
+		  {"type": "accounts", "id": "%s", "version": 1}
+		],
+		"links": {
+		  "first": "/v1/organisation/accounts?page%%5Bnumber%%5D=first",
+		  "last": "/v1/organisation/accounts?page%%5Bnumber%%5D=last",
+		  "next": "/v1/organisation/accounts?page%%5Bnumber%%5D=2&page%%5Bsize%%5D=2",
+		  "prev": "/v1/organisation/accounts?page%%5Bnumber%%5D=0&page%%5Bsize%%5D=2",
+		  "self": "/v1/organisation/accounts?page%%5Bnumber%%5D=1&page%%5Bsize%%5D=2"
+		}
+	  }`, id1, id2)
+
+	var gotReq *http.Request
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	page, err := accClient.List(ctx, &ListOptions{PageNumber: 1, PageSize: 2})
+
+	require.NoError(t, err)
+	require.NotNil(t, page)
+	assert.Equal(t, "GET", gotReq.Method)
+	assert.Equal(t, "/v1/organisation/accounts", gotReq.URL.Path)
+	assert.Equal(t, "1", gotReq.URL.Query().Get("page[number]"))
+	assert.Equal(t, "2", gotReq.URL.Query().Get("page[size]"))
+
+	require.Len(t, page.Accounts, 2)
+	assert.Equal(t, id1, *page.Accounts[0].ID)
+	assert.Equal(t, id2, *page.Accounts[1].ID)
+	assert.Equal(t, "/v1/organisation/accounts?page%5Bnumber%5D=2&page%5Bsize%5D=2", page.Links.Next)
+	assert.Equal(t, "/v1/organisation/accounts?page%5Bnumber%5D=0&page%5Bsize%5D=2", page.Links.Prev)
+	assert.Equal(t, "/v1/organisation/accounts?page%5Bnumber%5D=first", page.Links.First)
+	assert.Equal(t, "/v1/organisation/accounts?page%5Bnumber%5D=last", page.Links.Last)
+	assert.True(t, page.HasNext())
+}
+
+func TestListAccountsNilOptions(t *testing.T) {
+	var gotReq *http.Request
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"data": [], "links": {}}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	page, err := accClient.List(context.Background(), nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, gotReq.URL.RawQuery)
+	assert.Empty(t, page.Accounts)
+	assert.False(t, page.HasNext())
+}
+
+func TestListAccountsNextPage(t *testing.T) {
+	var gotReq *http.Request
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"data": [{"type": "accounts"}], "links": {}}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	page := &AccountPage{Links: Links{Next: "/v1/organisation/accounts?page%5Bnumber%5D=3"}}
+	next, err := accClient.NextPage(context.Background(), page)
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/v1/organisation/accounts?page%5Bnumber%5D=3", gotReq.URL.String())
+	assert.Len(t, next.Accounts, 1)
+	assert.False(t, next.HasNext())
+
+	last, err := accClient.NextPage(context.Background(), next)
+	assert.ErrorIs(t, err, ErrNoNextPage)
+	assert.Nil(t, last)
+}
+
+func TestListAccountsNextPageBaseURLPath(t *testing.T) {
+	tests := map[string]struct {
+		baseURL string
+		link    string
+		want    string
+	}{
+		"no path": {
+			baseURL: "https://api.example.com",
+			link:    "/v1/organisation/accounts?page%5Bnumber%5D=3",
+			want:    "https://api.example.com/v1/organisation/accounts?page%5Bnumber%5D=3",
+		},
+		"path prefix": {
+			baseURL: "https://gateway.example.com/api",
+			link:    "/v1/organisation/accounts?page%5Bnumber%5D=3",
+			want:    "https://gateway.example.com/api/v1/organisation/accounts?page%5Bnumber%5D=3",
+		},
+		"link relative to the prefix": {
+			baseURL: "https://gateway.example.com/api",
+			link:    "v1/organisation/accounts?page%5Bnumber%5D=3",
+			want:    "https://gateway.example.com/api/v1/organisation/accounts?page%5Bnumber%5D=3",
+		},
+		"link already prefixed": {
+			baseURL: "https://gateway.example.com/api",
+			link:    "/api/v1/organisation/accounts?page%5Bnumber%5D=3",
+			want:    "https://gateway.example.com/api/v1/organisation/accounts?page%5Bnumber%5D=3",
+		},
+		"absolute link": {
+			baseURL: "https://gateway.example.com/api",
+			link:    "https://other.example.com/v1/organisation/accounts?page%5Bnumber%5D=3",
+			want:    "https://other.example.com/v1/organisation/accounts?page%5Bnumber%5D=3",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var gotReq *http.Request
+			mock := client.MockClient{}
+			mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+				gotReq = req
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"data": [], "links": {}}`))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithBaseURL(tc.baseURL))
+			require.NoError(t, err)
+
+			_, err = accClient.NextPage(context.Background(), &AccountPage{Links: Links{Next: tc.link}})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, gotReq.URL.String())
+		})
+	}
+}
+
+func TestListAccountsErrors(t *testing.T) {
+	tests := map[string]struct {
+		code int
+		body string
+		err  error
+	}{
+		"bad request": {
+			code: 400,
+			body: `{"error_message": "invalid page size"}`,
+			err: &client.APIError{
+				ErrorMessage: "invalid page size",
+				StatusCode:   http.StatusBadRequest,
+			},
+		},
+		"service unavailable": {code: 503, body: "", err: &client.APIError{StatusCode: 503}},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mock := client.MockClient{}
+			mock.DoImpl = func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tc.code,
+					Body:       io.NopCloser(bytes.NewReader([]byte(tc.body))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+			require.NoError(t, err)
+
+			page, err := accClient.List(context.Background(), nil)
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, page)
+		})
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"context"
 	"errors"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"syscall"
 	"testing"
+	"time"
 
 	client "github.com/banjoh/fake-api-client"
 	"github.com/google/uuid"
@@ -24,6 +29,29 @@ type temporaryErr struct{}
 func (e *temporaryErr) Error() string   { return "timeout" }
 func (e *temporaryErr) Temporary() bool { return true }
 
+// validAccountCreate returns an account passing validation
+func validAccountCreate() *AccountCreate {
+	id := uuid.New()
+	oID := uuid.New()
+	return &AccountCreate{
+		Type:           "accounts",
+		ID:             &id,
+		OrganisationID: &oID,
+		Attributes:     validAttributes(),
+	}
+}
+
+// validAttributes returns the attributes of a GB account passing validation
+func validAttributes() *Attributes {
+	return &Attributes{
+		Country:    "GB",
+		BankID:     "400300",
+		BankIDCode: "GBDSC",
+		BIC:        "NWBKGB22",
+		Name:       []string{"John Doe"},
+	}
+}
+
 func TestReturningNonAPIError(t *testing.T) {
 	body := io.NopCloser(bytes.NewReader([]byte("")))
 	mock := client.MockClient{}
@@ -48,8 +76,7 @@ func TestReturningNonAPIError(t *testing.T) {
 	err = accClient.Delete(ctx, uuid.New(), 0)
 	assert.ErrorIs(t, err, genericErr)
 
-	accCreate := AccountCreate{}
-	acc, err = accClient.Create(ctx, &accCreate)
+	acc, err = accClient.Create(ctx, validAccountCreate())
 
 	assert.ErrorIs(t, err, genericErr)
 	assert.Nil(t, acc)
@@ -101,11 +128,308 @@ func TestRetryingCalls(t *testing.T) {
 			assert.ErrorIs(t, err, tc.expectedErr)
 			assert.Nil(t, acc)
 
-			assert.Equal(t, RetryCount, calls)
+			assert.Equal(t, client.DefaultRetryPolicy().MaxAttempts, calls)
+		})
+	}
+}
+
+func TestNotRetryingCalls(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		cancel bool
+	}{
+		"non network error":     {err: errors.New("signing failed")},
+		"permanent net error":   {err: &net.OpError{Op: "dial", Err: errors.New("no such host")}},
+		"cancelled during call": {err: &timeoutErr{}, cancel: true},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			mock := client.MockClient{}
+			mock.DoImpl = func(*http.Request) (*http.Response, error) {
+				calls++
+				if tc.cancel {
+					cancel()
+				}
+				return nil, tc.err
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+			require.NoError(t, err)
+
+			acc, err := accClient.Fetch(ctx, uuid.New())
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, acc)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestNotRetryingRateLimiterDeadline(t *testing.T) {
+	limiter, err := client.NewRateLimiter(0.01, 1)
+	require.NoError(t, err)
+	require.True(t, limiter.Allow())
+
+	calls := 0
+	mock := &client.MockClient{DoImpl: func(*http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("unexpected request")
+	}}
+	throttled, err := client.NewThrottledClient(mock, limiter)
+	require.NoError(t, err)
+
+	sleeper := &client.MockRetrySleeper{}
+	accClient, err := NewWithClient(throttled, sleeper)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	acc, err := accClient.Fetch(ctx, uuid.New())
+
+	assert.ErrorIs(t, err, client.ErrWaitExceedsDeadline)
+	assert.Nil(t, acc)
+	assert.Equal(t, 0, calls)
+	assert.Empty(t, sleeper.Slept, "a wait that cannot meet the deadline is not retried")
+}
+
+func TestRetryingConnectionResets(t *testing.T) {
+	for _, resetErr := range []error{syscall.ECONNRESET, io.EOF, io.ErrUnexpectedEOF} {
+		calls := 0
+		mock := client.MockClient{}
+		mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls < 3 {
+				return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: resetErr}
+			}
+			return &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}
+
+		accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+		require.NoError(t, err)
+
+		err = accClient.Delete(context.Background(), uuid.New(), 0)
+
+		assert.NoError(t, err, resetErr)
+		assert.Equal(t, 3, calls, resetErr)
+	}
+}
+
+func TestRetryingCallsPerResourcePolicy(t *testing.T) {
+	policies := map[string]client.RetryPolicy{
+		"no retries": client.NoRetryPolicy(),
+		"two attempts": {
+			MaxAttempts:          2,
+			RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+		"status not retryable": {
+			MaxAttempts:          3,
+			RetryableStatusCodes: []int{http.StatusBadGateway},
+		},
+	}
+	want := map[string]int{"no retries": 1, "two attempts": 2, "status not retryable": 1}
+
+	for name, policy := range policies {
+		name, policy := name, policy
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			mock := client.MockClient{}
+			mock.DoImpl = func(*http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithRetry(policy))
+			require.NoError(t, err)
+
+			err = accClient.Delete(context.Background(), uuid.New(), 0)
+
+			assert.ErrorIs(t, err, &client.APIError{StatusCode: http.StatusServiceUnavailable})
+			assert.Equal(t, want[name], calls)
+		})
+	}
+}
+
+func TestRetryingCallsBackoffDelays(t *testing.T) {
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	policy := client.DefaultRetryPolicy()
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = 5 * time.Second
+	policy.Jitter = client.AdditiveJitter{Max: time.Second, Rand: rand.New(rand.NewSource(1))} // nolint: gosec
+
+	// Draw the jitter the policy is expected to add from an identically seeded source
+	jitter := rand.New(rand.NewSource(1)) // nolint: gosec
+	want := []time.Duration{}
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		d += time.Duration(jitter.Int63n(int64(time.Second)))
+		if d > policy.MaxDelay {
+			d = policy.MaxDelay
+		}
+		want = append(want, d)
+	}
+
+	sleeper := &client.MockRetrySleeper{}
+	accClient, err := NewWithClient(&mock, sleeper, WithRetry(policy))
+	require.NoError(t, err)
+
+	_, err = accClient.Fetch(context.Background(), uuid.New())
+
+	assert.Error(t, err)
+	assert.Equal(t, want, sleeper.Slept)
+}
+
+func TestRetryingRateLimitedCalls(t *testing.T) {
+	tests := map[string]struct {
+		headers map[string]string
+		calls   int
+		slept   []time.Duration
+	}{
+		"retry after seconds": {
+			headers: map[string]string{"Retry-After": "3"},
+			calls:   3,
+			slept:   []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		"quota reset": {
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "7"},
+			calls:   3,
+			slept:   []time.Duration{7 * time.Second, 7 * time.Second},
+		},
+		"no hints": {
+			calls: 3,
+			slept: []time.Duration{time.Second, time.Second},
+		},
+		"quota reset in the past": {
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1000000000"},
+			calls:   3,
+			slept:   []time.Duration{time.Second, time.Second},
+		},
+		"wait exceeds max delay": {
+			headers: map[string]string{"Retry-After": "3600"},
+			calls:   1,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			mock := client.MockClient{}
+			mock.DoImpl = func(*http.Request) (*http.Response, error) {
+				calls++
+				h := http.Header{}
+				for k, v := range tc.headers {
+					h.Set(k, v)
+				}
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     h,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"error_message": "too many requests"}`))),
+				}, nil
+			}
+
+			policy := client.RetryPolicy{
+				MaxAttempts:          3,
+				BaseDelay:            time.Second,
+				MaxDelay:             time.Minute,
+				RetryableStatusCodes: []int{http.StatusTooManyRequests},
+			}
+			sleeper := &client.MockRetrySleeper{}
+			accClient, err := NewWithClient(&mock, sleeper, WithRetry(policy))
+			require.NoError(t, err)
+
+			acc, err := accClient.Fetch(context.Background(), uuid.New())
+
+			var rateLimited *client.RateLimitedError
+			require.True(t, errors.As(err, &rateLimited))
+			assert.ErrorIs(t, err, &client.APIError{
+				ErrorMessage: "too many requests",
+				StatusCode:   http.StatusTooManyRequests,
+			})
+			assert.Nil(t, acc)
+			assert.Equal(t, tc.calls, calls)
+			assert.Equal(t, tc.slept, sleeper.Slept)
 		})
 	}
 }
 
+func TestRateLimitedCallCancelledWhileWaiting(t *testing.T) {
+	calls := 0
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"20"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.DefaultRetrySleeper{}, WithRetry(client.RetryPolicy{
+		MaxAttempts:          3,
+		MaxDelay:             30 * time.Second,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	acc, err := accClient.Fetch(ctx, uuid.New())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, acc)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second), "must stop waiting, not after 20s")
+}
+
+func TestRateLimitedCallRecovers(t *testing.T) {
+	calls := 0
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": {"2"}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusNoContent,
+			Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	sleeper := &client.MockRetrySleeper{}
+	accClient, err := NewWithClient(&mock, sleeper)
+	require.NoError(t, err)
+
+	err = accClient.Delete(context.Background(), uuid.New(), 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []time.Duration{2 * time.Second}, sleeper.Slept)
+}
+
 func TestConstructingClientNilHTTPClient(t *testing.T) {
 	r, err := NewWithClient(nil, &client.MockRetrySleeper{})
 	assert.Error(t, err)
@@ -0,0 +1,235 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func accountBody(id uuid.UUID, version int, name string) string {
+	return fmt.Sprintf(`{
+		"data": {
+		  "type": "accounts",
+		  "id": "%s",
+		  "version": %d,
+		  "attributes": {
+			"country": "GB",
+			"name": ["%s"]
+		  }
+		}
+	  }`, id, version, name)
+}
+
+func TestUpdateAccountSuccess(t *testing.T) {
+	id := uuid.New()
+
+	var gotReq *http.Request
+	var gotBody []byte
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		gotBody, _ = io.ReadAll(req.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(accountBody(id, 4, "Jane Doe")))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	patch := AccountUpdate{Attributes: &Attributes{Name: []string{"Jane Doe"}}}
+	acc, err := accClient.Update(context.Background(), id, 3, &patch)
+
+	require.NoError(t, err)
+	assert.Equal(t, "PATCH", gotReq.Method)
+	assert.Equal(t, "/v1/organisation/accounts/"+id.String(), gotReq.URL.Path)
+	assert.Equal(t, defaultContentType, gotReq.Header.Get("Content-Type"))
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"data": {
+		  "type": "accounts",
+		  "id": "%s",
+		  "version": 3,
+		  "attributes": {"name": ["Jane Doe"]}
+		}
+	  }`, id), string(gotBody))
+
+	assert.Equal(t, id, *acc.ID)
+	assert.Equal(t, 4, *acc.Version)
+	assert.Equal(t, []string{"Jane Doe"}, acc.Attributes.Name)
+}
+
+func TestUpdateAccountVersionConflict(t *testing.T) {
+	id := uuid.New()
+
+	mock := client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusConflict,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"error_message": "invalid version"}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, err := accClient.Update(context.Background(), id, 1, &AccountUpdate{})
+
+	assert.Nil(t, acc)
+
+	var conflict *VersionConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, id, conflict.ID)
+	assert.Equal(t, 1, conflict.Version)
+	assert.ErrorIs(t, err, &client.APIError{
+		ErrorMessage: "invalid version",
+		StatusCode:   http.StatusConflict,
+	})
+}
+
+func TestUpdateAccountErrors(t *testing.T) {
+	tests := map[string]struct {
+		code int
+		body string
+		err  error
+	}{
+		"not found": {code: 404, body: "", err: &client.APIError{StatusCode: 404}},
+		"bad request": {
+			code: 400,
+			body: `{"error_message": "validation failure"}`,
+			err: &client.APIError{
+				ErrorMessage: "validation failure",
+				StatusCode:   http.StatusBadRequest,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mock := client.MockClient{}
+			mock.DoImpl = func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tc.code,
+					Body:       io.NopCloser(bytes.NewReader([]byte(tc.body))),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+			require.NoError(t, err)
+
+			acc, err := accClient.Update(context.Background(), uuid.New(), 0, &AccountUpdate{})
+
+			var conflict *VersionConflictError
+			assert.False(t, errors.As(err, &conflict))
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, acc)
+		})
+	}
+}
+
+func TestUpdateAccountNilPatch(t *testing.T) {
+	accClient, err := NewWithClient(&client.MockClient{}, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, err := accClient.Update(context.Background(), uuid.New(), 0, nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, acc)
+}
+
+// versionedMock emulates a server holding a single account whose version is
+// bumped behind the client's back for the first `races` updates
+func versionedMock(t *testing.T, id uuid.UUID, races int) (*client.MockClient, *int) {
+	version := 0
+	updates := 0
+
+	mock := &client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		switch req.Method {
+		case "GET":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(accountBody(id, version, "John Doe")))),
+			}, nil
+		case "PATCH":
+			updates++
+
+			var dto AccountDTO
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&dto))
+
+			if updates <= races {
+				version++
+			}
+			if *dto.Data.Version != version {
+				return &http.Response{
+					StatusCode: http.StatusConflict,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"error_message": "invalid version"}`))),
+				}, nil
+			}
+
+			version++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(accountBody(id, version, dto.Data.Attributes.Name[0])))),
+			}, nil
+		}
+
+		t.Fatalf("unexpected method: %s", req.Method)
+		return nil, nil
+	}
+
+	return mock, &updates
+}
+
+func TestUpdateWithRefetchRetriesConflicts(t *testing.T) {
+	id := uuid.New()
+	mock, updates := versionedMock(t, id, 2)
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	patch := AccountUpdate{Attributes: &Attributes{Name: []string{"Jane Doe"}}}
+	acc, err := accClient.UpdateWithRefetch(context.Background(), id, &patch, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, *updates)
+	assert.Equal(t, 3, *acc.Version)
+	assert.Equal(t, []string{"Jane Doe"}, acc.Attributes.Name)
+}
+
+func TestUpdateWithRefetchGivesUp(t *testing.T) {
+	id := uuid.New()
+	mock, updates := versionedMock(t, id, 5)
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	patch := AccountUpdate{Attributes: &Attributes{Name: []string{"Jane Doe"}}}
+	acc, err := accClient.UpdateWithRefetch(context.Background(), id, &patch, 3)
+
+	var conflict *VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Nil(t, acc)
+	assert.Equal(t, 3, *updates)
+}
+
+func TestUpdateWithRefetchInvalidAttempts(t *testing.T) {
+	accClient, err := NewWithClient(&client.MockClient{}, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	acc, err := accClient.UpdateWithRefetch(context.Background(), uuid.New(), &AccountUpdate{}, 0)
+
+	assert.Error(t, err)
+	assert.Nil(t, acc)
+}
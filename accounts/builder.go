@@ -0,0 +1,241 @@
+package accounts
+
+import (
+	"github.com/google/uuid"
+)
+
+// preset holds the values a country's accounts are created with by default
+type preset struct {
+	bankIDCode BankIDCode
+	currency   string
+}
+
+var presets = map[string]preset{
+	"GB": {bankIDCode: BankIDCodeGBDSC, currency: "GBP"},
+	"AU": {bankIDCode: BankIDCodeAUBSB, currency: "AUD"},
+	"BE": {bankIDCode: BankIDCodeBE, currency: "EUR"},
+	"CA": {bankIDCode: BankIDCodeCACPA, currency: "CAD"},
+	"FR": {bankIDCode: BankIDCodeFR, currency: "EUR"},
+	"DE": {bankIDCode: BankIDCodeDEBLZ, currency: "EUR"},
+	"GR": {bankIDCode: BankIDCodeGRBIC, currency: "EUR"},
+	"HK": {bankIDCode: BankIDCodeHKNCC, currency: "HKD"},
+	"IT": {bankIDCode: BankIDCodeITNCC, currency: "EUR"},
+	"LU": {bankIDCode: BankIDCodeLULUX, currency: "EUR"},
+	"NL": {currency: "EUR"},
+	"PL": {bankIDCode: BankIDCodePLKNR, currency: "PLN"},
+	"PT": {bankIDCode: BankIDCodePTNCC, currency: "EUR"},
+	"ES": {bankIDCode: BankIDCodeESNCC, currency: "EUR"},
+	"CH": {bankIDCode: BankIDCodeCHBCC, currency: "CHF"},
+	"US": {bankIDCode: BankIDCodeUSABA, currency: "USD"},
+}
+
+// AccountBuilder builds AccountCreate payloads. The account type is set up
+// front and every Build generates a random ID, unless one is set with ID.
+// Setters return the builder so that calls can be chained, Build reports any
+// mistake once the account is complete:
+//
+//	acc, err := accounts.NewGBAccount("400300", "41426819").
+//		OrganisationID(orgID).
+//		BIC("NWBKGB22").
+//		Name("John Doe").
+//		Build()
+type AccountBuilder struct {
+	acc  AccountCreate
+	attr Attributes
+}
+
+// NewAccountBuilder starts an account held in the given country. The bank ID
+// code and base currency of the country are filled in for supported countries
+func NewAccountBuilder(country string) *AccountBuilder {
+	b := &AccountBuilder{
+		acc:  AccountCreate{Type: accountsType},
+		attr: Attributes{Country: country},
+	}
+
+	if p, ok := presets[country]; ok {
+		b.attr.BankIDCode = p.bankIDCode
+		b.attr.BaseCurrency = p.currency
+	}
+	return b
+}
+
+// NewGBAccount starts a GB account identified by its sort code and account number
+func NewGBAccount(sortCode, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("GB").BankID(sortCode).AccountNumber(accountNumber)
+}
+
+// NewAUAccount starts an AU account identified by its BSB code and account number
+func NewAUAccount(bsb, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("AU").BankID(bsb).AccountNumber(accountNumber)
+}
+
+// NewDEAccount starts a DE account identified by its Bankleitzahl and account number
+func NewDEAccount(blz, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("DE").BankID(blz).AccountNumber(accountNumber)
+}
+
+// NewFRAccount starts a FR account identified by its bank and branch code and
+// account number
+func NewFRAccount(bankID, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("FR").BankID(bankID).AccountNumber(accountNumber)
+}
+
+// NewESAccount starts an ES account identified by its bank and branch code and
+// account number
+func NewESAccount(bankID, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("ES").BankID(bankID).AccountNumber(accountNumber)
+}
+
+// NewNLAccount starts a NL account identified by the BIC of its bank and its
+// account number. NL accounts have no bank ID
+func NewNLAccount(bic, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("NL").BIC(bic).AccountNumber(accountNumber)
+}
+
+// NewUSAccount starts a US account identified by its ABA routing number and
+// account number
+func NewUSAccount(routingNumber, accountNumber string) *AccountBuilder {
+	return NewAccountBuilder("US").BankID(routingNumber).AccountNumber(accountNumber)
+}
+
+// ID sets the account ID, used by every account built from now on instead
+// of a generated one
+func (b *AccountBuilder) ID(id uuid.UUID) *AccountBuilder {
+	b.acc.ID = &id
+	return b
+}
+
+// OrganisationID sets the organisation the account belongs to
+func (b *AccountBuilder) OrganisationID(id uuid.UUID) *AccountBuilder {
+	b.acc.OrganisationID = &id
+	return b
+}
+
+// BankID sets the national bank identifier, e.g. the sort code of GB accounts
+func (b *AccountBuilder) BankID(bankID string) *AccountBuilder {
+	b.attr.BankID = bankID
+	return b
+}
+
+// BankIDCode replaces the bank ID code filled in for the country
+func (b *AccountBuilder) BankIDCode(code BankIDCode) *AccountBuilder {
+	b.attr.BankIDCode = code
+	return b
+}
+
+// BIC sets the SWIFT BIC of the bank holding the account
+func (b *AccountBuilder) BIC(bic string) *AccountBuilder {
+	b.attr.BIC = bic
+	return b
+}
+
+// AccountNumber sets the national account number
+func (b *AccountBuilder) AccountNumber(number string) *AccountBuilder {
+	b.attr.AccountNumber = number
+	return b
+}
+
+// IBAN sets the IBAN of the account, see DeriveIBAN
+func (b *AccountBuilder) IBAN(iban string) *AccountBuilder {
+	b.attr.IBAN = iban
+	return b
+}
+
+// BaseCurrency replaces the currency filled in for the country, an ISO 4217 code
+func (b *AccountBuilder) BaseCurrency(currency string) *AccountBuilder {
+	b.attr.BaseCurrency = currency
+	return b
+}
+
+// CustomerID sets the reference of the account holder in the caller's systems
+func (b *AccountBuilder) CustomerID(id string) *AccountBuilder {
+	b.attr.CustomerID = id
+	return b
+}
+
+// Name sets the names of the account holder, 1 to 4 of them
+func (b *AccountBuilder) Name(names ...string) *AccountBuilder {
+	b.attr.Name = append([]string(nil), names...)
+	return b
+}
+
+// AlternativeNames sets the other names the account holder is known by
+func (b *AccountBuilder) AlternativeNames(names ...string) *AccountBuilder {
+	b.attr.AlternativeNames = append([]string(nil), names...)
+	return b
+}
+
+// Classification tells personal accounts from business accounts
+func (b *AccountBuilder) Classification(c AccountClassification) *AccountBuilder {
+	b.attr.AccountClassification = c
+	return b
+}
+
+// JointAccount sets whether the account is held by several people
+func (b *AccountBuilder) JointAccount(joint bool) *AccountBuilder {
+	b.attr.JointAccount = &joint
+	return b
+}
+
+// AccountMatchingOptOut sets whether the account is left out of account
+// matching, the Confirmation of Payee scheme
+func (b *AccountBuilder) AccountMatchingOptOut(optOut bool) *AccountBuilder {
+	b.attr.AccountMatchingOptOut = &optOut
+	return b
+}
+
+// SecondaryIdentification sets the additional reference some banks need to
+// identify the account, e.g. a building society roll number
+func (b *AccountBuilder) SecondaryIdentification(id string) *AccountBuilder {
+	b.attr.SecondaryIdentification = id
+	return b
+}
+
+// Switched sets whether the account was moved to another bank
+func (b *AccountBuilder) Switched(switched bool) *AccountBuilder {
+	b.attr.Switched = &switched
+	return b
+}
+
+// Build validates the account and returns it, or the *ValidationError
+// returned by AccountCreate.Validate. The builder can be reused, accounts
+// built from it do not share any state and get their own random ID unless
+// one was set with ID
+func (b *AccountBuilder) Build() (*AccountCreate, error) {
+	acc := b.acc
+	acc.ID = copyUUID(b.acc.ID)
+	if acc.ID == nil {
+		id := uuid.New()
+		acc.ID = &id
+	}
+	acc.OrganisationID = copyUUID(b.acc.OrganisationID)
+
+	attr := b.attr
+	attr.Name = append([]string(nil), b.attr.Name...)
+	attr.AlternativeNames = append([]string(nil), b.attr.AlternativeNames...)
+	attr.JointAccount = copyBool(b.attr.JointAccount)
+	attr.AccountMatchingOptOut = copyBool(b.attr.AccountMatchingOptOut)
+	attr.Switched = copyBool(b.attr.Switched)
+	acc.Attributes = &attr
+
+	if err := acc.Validate(); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func copyUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
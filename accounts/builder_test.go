@@ -0,0 +1,150 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildGBAccount(t *testing.T) {
+	oID := uuid.New()
+
+	acc, err := NewGBAccount("400300", "41426819").
+		OrganisationID(oID).
+		BIC("NWBKGB22").
+		Name("John Doe").
+		Classification(ClassificationPersonal).
+		JointAccount(false).
+		Switched(true).
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, "accounts", acc.Type)
+	require.NotNil(t, acc.ID)
+	assert.NotEqual(t, uuid.Nil, *acc.ID)
+	assert.Equal(t, oID, *acc.OrganisationID)
+
+	no, yes := false, true
+	assert.Equal(t, &Attributes{
+		Country:               "GB",
+		BaseCurrency:          "GBP",
+		BankID:                "400300",
+		BankIDCode:            BankIDCodeGBDSC,
+		BIC:                   "NWBKGB22",
+		AccountNumber:         "41426819",
+		Name:                  []string{"John Doe"},
+		AccountClassification: ClassificationPersonal,
+		JointAccount:          &no,
+		Switched:              &yes,
+	}, acc.Attributes)
+}
+
+func TestBuildCountryPresets(t *testing.T) {
+	oID := uuid.New()
+
+	tests := map[string]struct {
+		builder *AccountBuilder
+		want    Attributes
+	}{
+		"AU": {
+			builder: NewAUAccount("123456", "1234567").BIC("NATAAU33"),
+			want:    Attributes{Country: "AU", BaseCurrency: "AUD", BankIDCode: BankIDCodeAUBSB, BankID: "123456", AccountNumber: "1234567", BIC: "NATAAU33"},
+		},
+		"DE": {
+			builder: NewDEAccount("37040044", "5320130"),
+			want:    Attributes{Country: "DE", BaseCurrency: "EUR", BankIDCode: BankIDCodeDEBLZ, BankID: "37040044", AccountNumber: "5320130"},
+		},
+		"FR": {
+			builder: NewFRAccount("2004101005", "0500013M026"),
+			want:    Attributes{Country: "FR", BaseCurrency: "EUR", BankIDCode: BankIDCodeFR, BankID: "2004101005", AccountNumber: "0500013M026"},
+		},
+		"ES": {
+			builder: NewESAccount("21000418", "0200051332"),
+			want:    Attributes{Country: "ES", BaseCurrency: "EUR", BankIDCode: BankIDCodeESNCC, BankID: "21000418", AccountNumber: "0200051332"},
+		},
+		"NL": {
+			builder: NewNLAccount("ABNANL2A", "0417164300"),
+			want:    Attributes{Country: "NL", BaseCurrency: "EUR", BIC: "ABNANL2A", AccountNumber: "0417164300"},
+		},
+		"US": {
+			builder: NewUSAccount("021000021", "123456789").BIC("CHASUS33"),
+			want:    Attributes{Country: "US", BaseCurrency: "USD", BankIDCode: BankIDCodeUSABA, BankID: "021000021", AccountNumber: "123456789", BIC: "CHASUS33"},
+		},
+		"CH": {
+			builder: NewAccountBuilder("CH").BankID("00762").BaseCurrency("EUR"),
+			want:    Attributes{Country: "CH", BaseCurrency: "EUR", BankIDCode: BankIDCodeCHBCC, BankID: "00762"},
+		},
+		"unsupported country": {
+			builder: NewAccountBuilder("JP").BankID("0001"),
+			want:    Attributes{Country: "JP", BankID: "0001"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			acc, err := tc.builder.OrganisationID(oID).Name("John Doe").Build()
+			require.NoError(t, err)
+
+			tc.want.Name = []string{"John Doe"}
+			assert.Equal(t, &tc.want, acc.Attributes)
+		})
+	}
+
+	for country := range presets {
+		_, ok := RulesFor(country)
+		assert.True(t, ok, "presets only cover supported countries: %s", country)
+	}
+}
+
+func TestBuildValidates(t *testing.T) {
+	acc, err := NewGBAccount("4003", "41426819").Name("John Doe").Build()
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"organisation_id", "attributes.bank_id", "attributes.bic"}, validationErr.Fields())
+	assert.Nil(t, acc)
+}
+
+func TestBuilderReuse(t *testing.T) {
+	id := uuid.New()
+	b := NewGBAccount("400300", "41426819").
+		ID(id).
+		OrganisationID(uuid.New()).
+		BIC("NWBKGB22").
+		Name("John Doe").
+		JointAccount(true)
+
+	first, err := b.Build()
+	require.NoError(t, err)
+
+	*first.ID = uuid.New()
+	first.Attributes.Name[0] = "Jane Doe"
+	*first.Attributes.JointAccount = false
+
+	second, err := b.JointAccount(true).Build()
+	require.NoError(t, err)
+	assert.Equal(t, id, *second.ID)
+	assert.Equal(t, []string{"John Doe"}, second.Attributes.Name)
+	assert.True(t, *second.Attributes.JointAccount)
+}
+
+func TestBuilderGeneratesIDs(t *testing.T) {
+	first, err := NewGBAccount("400300", "41426819").OrganisationID(uuid.New()).BIC("NWBKGB22").Name("John Doe").Build()
+	require.NoError(t, err)
+	second, err := NewGBAccount("400300", "41426819").OrganisationID(uuid.New()).BIC("NWBKGB22").Name("John Doe").Build()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, *first.ID, *second.ID)
+
+	b := NewGBAccount("400300", "41426819").OrganisationID(uuid.New()).BIC("NWBKGB22").Name("John Doe")
+	first, err = b.Build()
+	require.NoError(t, err)
+	second, err = b.Build()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, *first.ID, *second.ID, "a reused builder does not repeat its ID")
+}
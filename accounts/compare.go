@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"reflect"
+	"strings"
+)
+
+// diffAccount lists the JSON names of the fields requested in want whose value
+// differs in got, e.g. "attributes.country". Fields left empty in want are not
+// compared since the server is free to fill them in
+func diffAccount(want *AccountCreate, got *Account) []string {
+	var diff []string
+
+	if want.Type != "" && want.Type != got.Type {
+		diff = append(diff, "type")
+	}
+	if want.ID != nil && (got.ID == nil || *want.ID != *got.ID) {
+		diff = append(diff, "id")
+	}
+	if want.OrganisationID != nil && (got.OrganisationID == nil || *want.OrganisationID != *got.OrganisationID) {
+		diff = append(diff, "organisation_id")
+	}
+
+	if want.Attributes == nil {
+		return diff
+	}
+
+	var gotAttr Attributes
+	if got.Attributes != nil {
+		gotAttr = *got.Attributes
+	}
+
+	wv := reflect.ValueOf(*want.Attributes)
+	gv := reflect.ValueOf(gotAttr)
+	for i := 0; i < wv.NumField(); i++ {
+		if wv.Field(i).IsZero() {
+			continue
+		}
+
+		if !reflect.DeepEqual(wv.Field(i).Interface(), gv.Field(i).Interface()) {
+			name := strings.Split(wv.Type().Field(i).Tag.Get("json"), ",")[0]
+			diff = append(diff, "attributes."+name)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,58 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffAccount(t *testing.T) {
+	id := uuid.New()
+	oID := uuid.New()
+	yes, no := true, false
+
+	want := AccountCreate{
+		Type:           "accounts",
+		ID:             &id,
+		OrganisationID: &oID,
+		Attributes: &Attributes{
+			Country:      "GB",
+			Name:         []string{"John Doe"},
+			JointAccount: &yes,
+		},
+	}
+
+	tests := map[string]struct {
+		got  Account
+		diff []string
+	}{
+		"identical": {
+			got: Account{Type: "accounts", ID: &id, OrganisationID: &oID, Attributes: &Attributes{
+				Country: "GB", Name: []string{"John Doe"}, JointAccount: &yes,
+			}},
+		},
+		"server filled in fields": {
+			got: Account{Type: "accounts", ID: &id, OrganisationID: &oID, Attributes: &Attributes{
+				Country: "GB", Name: []string{"John Doe"}, JointAccount: &yes, Status: "confirmed", BIC: "NWBKGB22",
+			}},
+		},
+		"differing fields": {
+			got: Account{Type: "accounts", ID: &id, Attributes: &Attributes{
+				Country: "FR", Name: []string{"Jane Doe"}, JointAccount: &no,
+			}},
+			diff: []string{"organisation_id", "attributes.country", "attributes.name", "attributes.joint_account"},
+		},
+		"no attributes": {
+			got:  Account{Type: "accounts", ID: &id, OrganisationID: &oID},
+			diff: []string{"attributes.country", "attributes.name", "attributes.joint_account"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.diff, diffAccount(&want, &tc.got))
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package accounts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/banjoh/fake-api-client/iban"
+)
+
+// UnsupportedDerivationError is returned by DeriveIBAN for countries whose
+// IBAN cannot be derived, including countries not using IBANs at all
+type UnsupportedDerivationError struct {
+	Country string
+}
+
+func (e *UnsupportedDerivationError) Error() string {
+	return fmt.Sprintf("iban derivation not supported for country %q", e.Country)
+}
+
+// bbanBuilders build the national account number of the countries the
+// platform derives IBANs for
+var bbanBuilders = map[string]func(attr *Attributes) (string, error){
+	"BE": bbanBE,
+	"CH": simpleBBAN(5, 12),
+	"DE": simpleBBAN(8, 10),
+	"ES": bbanES,
+	"FR": bbanFR,
+	"GB": bbanWithBIC(6, 8),
+	"GR": simpleBBAN(7, 16),
+	"IT": bbanIT,
+	"LU": simpleBBAN(3, 13),
+	"NL": bbanWithBIC(0, 10),
+	"PL": simpleBBAN(8, 16),
+	"PT": bbanPT,
+}
+
+// DeriveIBAN builds the IBAN the platform derives from the country, bank ID
+// and account number of an account, national check digits included. GB and
+// NL IBANs carry the bank code held by the first 4 characters of the BIC.
+// The bank ID and account number must follow the rules of the country, see
+// RulesFor. DE and NL account numbers shorter than 10 digits are padded with
+// leading zeros. An *UnsupportedDerivationError is returned for countries the
+// platform does not derive IBANs for
+func DeriveIBAN(attr *Attributes) (string, error) {
+	if attr == nil {
+		return "", fmt.Errorf("accounts.DeriveIBAN: nil Attributes")
+	}
+
+	build, ok := bbanBuilders[attr.Country]
+	if !ok {
+		return "", &UnsupportedDerivationError{Country: attr.Country}
+	}
+
+	if attr.AccountNumber == "" {
+		return "", fmt.Errorf("accounts.DeriveIBAN: account number is required")
+	}
+
+	// Only derive IBANs for the bank details Validate accepts
+	rules := countryRules[attr.Country]
+	for _, f := range []struct {
+		field string
+		value string
+		rule  FieldRule
+	}{
+		{"attributes.bank_id", attr.BankID, rules.BankID},
+		{"attributes.account_number", attr.AccountNumber, rules.AccountNumber},
+	} {
+		if msg := f.rule.check(attr.Country, f.value); msg != "" {
+			return "", fmt.Errorf("accounts.DeriveIBAN: %w", FieldError{Field: f.field, Message: msg})
+		}
+	}
+
+	bban, err := build(attr)
+	if err != nil {
+		return "", fmt.Errorf("accounts.DeriveIBAN: %w", err)
+	}
+
+	i, err := iban.New(attr.Country, bban)
+	if err != nil {
+		return "", fmt.Errorf("accounts.DeriveIBAN: %w", err)
+	}
+	return i.String(), nil
+}
+
+// simpleBBAN builds BBANs made of the bank ID followed by the account number
+func simpleBBAN(bankIDLen, accountLen int) func(attr *Attributes) (string, error) {
+	return func(attr *Attributes) (string, error) {
+		bankID, err := fixed("bank id", attr.BankID, bankIDLen)
+		if err != nil {
+			return "", err
+		}
+		account, err := padded("account number", attr.AccountNumber, accountLen)
+		if err != nil {
+			return "", err
+		}
+		return bankID + account, nil
+	}
+}
+
+// bbanWithBIC builds BBANs starting with the bank code of the BIC, followed
+// by the bank ID, if any, and the account number
+func bbanWithBIC(bankIDLen, accountLen int) func(attr *Attributes) (string, error) {
+	return func(attr *Attributes) (string, error) {
+		bic, err := iban.ParseBIC(attr.BIC)
+		if err != nil {
+			return "", err
+		}
+
+		bankID := ""
+		if bankIDLen > 0 {
+			if bankID, err = fixed("bank id", attr.BankID, bankIDLen); err != nil {
+				return "", err
+			}
+		}
+
+		account, err := padded("account number", attr.AccountNumber, accountLen)
+		if err != nil {
+			return "", err
+		}
+		return bic.Institution + bankID + account, nil
+	}
+}
+
+// bbanBE appends the national check digits, the first 10 digits modulo 97
+func bbanBE(attr *Attributes) (string, error) {
+	bban, err := simpleBBAN(3, 7)(attr)
+	if err != nil {
+		return "", err
+	}
+
+	check := iban.Mod97(bban)
+	if check == 0 {
+		check = 97
+	}
+	return bban + fmt.Sprintf("%02d", check), nil
+}
+
+// bbanFR appends the clé RIB to the bank and branch codes and account number
+func bbanFR(attr *Attributes) (string, error) {
+	bankID, err := fixed("bank id", attr.BankID, 10)
+	if err != nil {
+		return "", err
+	}
+	account, err := padded("account number", attr.AccountNumber, 11)
+	if err != nil {
+		return "", err
+	}
+
+	// Letters of the account number count as digits, A and J as 1, B, K and S as 2...
+	digits := strings.Map(func(r rune) rune {
+		if r < 'A' || r > 'Z' {
+			return r
+		}
+		rank := r - 'A'
+		if r >= 'S' {
+			rank++
+		}
+		return '1' + rank%9
+	}, bankID+account)
+
+	bank, _ := strconv.ParseInt(digits[:5], 10, 64)
+	branch, _ := strconv.ParseInt(digits[5:10], 10, 64)
+	number, _ := strconv.ParseInt(digits[10:], 10, 64)
+	key := 97 - (89*bank+15*branch+3*number)%97
+
+	return bankID + account + fmt.Sprintf("%02d", key), nil
+}
+
+// bbanES inserts the two control digits between the bank and branch codes
+// and the account number
+func bbanES(attr *Attributes) (string, error) {
+	bankID, err := fixed("bank id", attr.BankID, 8)
+	if err != nil {
+		return "", err
+	}
+	account, err := padded("account number", attr.AccountNumber, 10)
+	if err != nil {
+		return "", err
+	}
+
+	return bankID + esControl("00"+bankID) + esControl(account) + account, nil
+}
+
+func esControl(digits string) string {
+	weights := []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+	sum := 0
+	for i, c := range digits {
+		sum += int(c-'0') * weights[i]
+	}
+
+	switch d := 11 - sum%11; d {
+	case 11:
+		return "0"
+	case 10:
+		return "1"
+	default:
+		return strconv.Itoa(d)
+	}
+}
+
+// bbanIT prepends the CIN check character to the ABI and CAB codes and
+// account number
+func bbanIT(attr *Attributes) (string, error) {
+	bankID, err := fixed("bank id", attr.BankID, 10)
+	if err != nil {
+		return "", err
+	}
+	account, err := padded("account number", attr.AccountNumber, 12)
+	if err != nil {
+		return "", err
+	}
+
+	// Characters in odd positions are weighted through this table, indexed by
+	// digit value or letter rank, characters in even positions by their value
+	odd := []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+	sum := 0
+	for i, c := range bankID + account {
+		v := int(c - '0')
+		if c >= 'A' && c <= 'Z' {
+			v = int(c - 'A')
+		}
+		if i%2 == 0 {
+			sum += odd[v]
+		} else {
+			sum += v
+		}
+	}
+
+	return string(rune('A'+sum%26)) + bankID + account, nil
+}
+
+// bbanPT appends the NIB check digits to the bank and branch codes and
+// account number
+func bbanPT(attr *Attributes) (string, error) {
+	bban, err := simpleBBAN(8, 11)(attr)
+	if err != nil {
+		return "", err
+	}
+
+	return bban + iban.Mod97CheckDigits(bban), nil
+}
+
+// fixed checks that value has exactly n digits or upper case letters
+func fixed(field, value string, n int) (string, error) {
+	if len(value) != n || !isAlphanumeric(value) {
+		return "", fmt.Errorf("%s must be %d characters long, got %q", field, n, value)
+	}
+	return value, nil
+}
+
+// padded left pads value with zeros to n characters
+func padded(field, value string, n int) (string, error) {
+	if len(value) > n || !isAlphanumeric(value) {
+		return "", fmt.Errorf("%s must be at most %d characters long, got %q", field, n, value)
+	}
+	return strings.Repeat("0", n-len(value)) + value, nil
+}
+
+func isAlphanumeric(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
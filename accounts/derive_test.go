@@ -0,0 +1,166 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/banjoh/fake-api-client/iban"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveIBAN(t *testing.T) {
+	// Examples published in the SWIFT IBAN registry
+	tests := map[string]struct {
+		attr Attributes
+		want string
+	}{
+		"GB": {attr: Attributes{BankID: "601613", BIC: "NWBKGB2L", AccountNumber: "31926819"}, want: "GB29NWBK60161331926819"},
+		"BE": {attr: Attributes{BankID: "539", AccountNumber: "0075470"}, want: "BE68539007547034"},
+		"CH": {attr: Attributes{BankID: "00762", AccountNumber: "011623852957"}, want: "CH9300762011623852957"},
+		"DE": {attr: Attributes{BankID: "37040044", AccountNumber: "532013000"}, want: "DE89370400440532013000"},
+		"ES": {attr: Attributes{BankID: "21000418", AccountNumber: "0200051332"}, want: "ES9121000418450200051332"},
+		"FR": {attr: Attributes{BankID: "2004101005", AccountNumber: "0500013M026"}, want: "FR1420041010050500013M02606"},
+		"GR": {attr: Attributes{BankID: "0110125", AccountNumber: "0000000012300695"}, want: "GR1601101250000000012300695"},
+		"IT": {attr: Attributes{BankID: "0542811101", AccountNumber: "000000123456"}, want: "IT60X0542811101000000123456"},
+		"LU": {attr: Attributes{BankID: "001", AccountNumber: "9400644750000"}, want: "LU280019400644750000"},
+		"NL": {attr: Attributes{BIC: "ABNANL2A", AccountNumber: "417164300"}, want: "NL91ABNA0417164300"},
+		"PL": {attr: Attributes{BankID: "10901014", AccountNumber: "0000071219812874"}, want: "PL61109010140000071219812874"},
+		"PT": {attr: Attributes{BankID: "00020123", AccountNumber: "12345678901"}, want: "PT50000201231234567890154"},
+	}
+
+	for country, tc := range tests {
+		country, tc := country, tc
+		t.Run(country, func(t *testing.T) {
+			tc.attr.Country = country
+
+			got, err := DeriveIBAN(&tc.attr)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.NoError(t, iban.Validate(got))
+		})
+	}
+
+	for country := range bbanBuilders {
+		assert.Contains(t, tests, country, "every derivable country is tested")
+	}
+}
+
+func TestDeriveIBANPassesValidation(t *testing.T) {
+	examples := []Attributes{
+		{Country: "GB", BankID: "601613", BankIDCode: BankIDCodeGBDSC, BIC: "NWBKGB2L", AccountNumber: "31926819"},
+		{Country: "BE", BankID: "539", BankIDCode: BankIDCodeBE, AccountNumber: "0075470"},
+		{Country: "CH", BankID: "00762", BankIDCode: BankIDCodeCHBCC, AccountNumber: "011623852957"},
+		{Country: "DE", BankID: "37040044", BankIDCode: BankIDCodeDEBLZ, AccountNumber: "532013000"},
+		{Country: "DE", BankID: "37040044", BankIDCode: BankIDCodeDEBLZ, AccountNumber: "1234567"},
+		{Country: "ES", BankID: "21000418", BankIDCode: BankIDCodeESNCC, AccountNumber: "0200051332"},
+		{Country: "FR", BankID: "2004101005", BankIDCode: BankIDCodeFR, AccountNumber: "0500013M026"},
+		{Country: "GR", BankID: "0110125", BankIDCode: BankIDCodeGRBIC, AccountNumber: "0000000012300695"},
+		{Country: "IT", BankID: "0542811101", BankIDCode: BankIDCodeITNCC, AccountNumber: "000000123456"},
+		{Country: "LU", BankID: "001", BankIDCode: BankIDCodeLULUX, AccountNumber: "9400644750000"},
+		{Country: "NL", BIC: "ABNANL2A", AccountNumber: "417164300"},
+		{Country: "NL", BIC: "ABNANL2A", AccountNumber: "0417164300"},
+		{Country: "PL", BankID: "10901014", BankIDCode: BankIDCodePLKNR, AccountNumber: "0000071219812874"},
+		{Country: "PT", BankID: "00020123", BankIDCode: BankIDCodePTNCC, AccountNumber: "12345678901"},
+	}
+
+	for _, attr := range examples {
+		attr := attr
+		t.Run(attr.Country+" "+attr.AccountNumber, func(t *testing.T) {
+			var err error
+			attr.IBAN, err = DeriveIBAN(&attr)
+			require.NoError(t, err)
+
+			acc := validAccountCreate()
+			attr.Name = acc.Attributes.Name
+			acc.Attributes = &attr
+
+			assert.NoError(t, acc.Validate())
+		})
+	}
+}
+
+func TestDeriveIBANRejectsInvalidDetails(t *testing.T) {
+	// Every country's account numbers rejected by Validate are rejected here too
+	tests := map[string]Attributes{
+		"FR 10 character account": {Country: "FR", BankID: "2004101005", AccountNumber: "0500013M02"},
+		"GB short account":        {Country: "GB", BankID: "601613", BIC: "NWBKGB2L", AccountNumber: "3192681"},
+		"BE short account":        {Country: "BE", BankID: "539", AccountNumber: "75470"},
+		"DE letters in account":   {Country: "DE", BankID: "37040044", AccountNumber: "53201300A"},
+		"IT check character":      {Country: "IT", BankID: "X0542811101", AccountNumber: "000000123456"},
+	}
+
+	for name, attr := range tests {
+		attr := attr
+		t.Run(name, func(t *testing.T) {
+			_, err := DeriveIBAN(&attr)
+			assert.Error(t, err)
+
+			acc := validAccountCreate()
+			attr.Name = acc.Attributes.Name
+			acc.Attributes = &attr
+			assert.Error(t, acc.Validate())
+		})
+	}
+}
+
+func TestDeriveIBANNationalCheckDigits(t *testing.T) {
+	tests := map[string]struct {
+		attr Attributes
+		want string
+	}{
+		"BE remainder of 0 becomes 97": {
+			attr: Attributes{Country: "BE", BankID: "000", AccountNumber: "0000097"},
+			want: "BE54000000009797",
+		},
+		"ES control digits of 0": {
+			attr: Attributes{Country: "ES", BankID: "20770024", AccountNumber: "3102575766"},
+			want: "ES7620770024003102575766",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := DeriveIBAN(&tc.attr)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDeriveIBANUnsupported(t *testing.T) {
+	for _, country := range []string{"AU", "CA", "HK", "US", "JP", ""} {
+		_, err := DeriveIBAN(&Attributes{Country: country, BankID: "123456", AccountNumber: "12345678"})
+
+		var unsupported *UnsupportedDerivationError
+		require.True(t, errors.As(err, &unsupported), country)
+		assert.Equal(t, country, unsupported.Country)
+	}
+}
+
+func TestDeriveIBANErrors(t *testing.T) {
+	tests := map[string]*Attributes{
+		"nil attributes":         nil,
+		"missing account number": {Country: "DE", BankID: "37040044"},
+		"short bank id":          {Country: "DE", BankID: "3704004", AccountNumber: "532013000"},
+		"long account number":    {Country: "DE", BankID: "37040044", AccountNumber: "05320130001"},
+		"lower case":             {Country: "FR", BankID: "2004101005", AccountNumber: "0500013m026"},
+		"letters in bank id":     {Country: "DE", BankID: "3704004A", AccountNumber: "532013000"},
+		"missing bic":            {Country: "GB", BankID: "601613", AccountNumber: "31926819"},
+		"invalid bic":            {Country: "NL", BIC: "ABNA", AccountNumber: "417164300"},
+		"missing bank id":        {Country: "ES", AccountNumber: "0200051332"},
+	}
+
+	for name, attr := range tests {
+		attr := attr
+		t.Run(name, func(t *testing.T) {
+			got, err := DeriveIBAN(attr)
+
+			assert.Error(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
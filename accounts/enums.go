@@ -0,0 +1,182 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// AccountClassification tells personal accounts from business accounts
+type AccountClassification string
+
+const (
+	ClassificationPersonal AccountClassification = "Personal"
+	ClassificationBusiness AccountClassification = "Business"
+)
+
+// Known reports whether c is one of the classifications the API accepts
+func (c AccountClassification) Known() bool {
+	switch c {
+	case ClassificationPersonal, ClassificationBusiness:
+		return true
+	default:
+		return false
+	}
+}
+
+// AccountStatus is the state of an account on the platform
+type AccountStatus string
+
+const (
+	StatusPending   AccountStatus = "pending"
+	StatusConfirmed AccountStatus = "confirmed"
+	StatusFailed    AccountStatus = "failed"
+)
+
+// Known reports whether s is one of the statuses the API returns
+func (s AccountStatus) Known() bool {
+	switch s {
+	case StatusPending, StatusConfirmed, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// BankIDCode identifies the national scheme Attributes.BankID belongs to
+type BankIDCode string
+
+const (
+	BankIDCodeGBDSC BankIDCode = "GBDSC" // GB sort code
+	BankIDCodeAUBSB BankIDCode = "AUBSB" // AU BSB code
+	BankIDCodeBE    BankIDCode = "BE"    // BE bank code
+	BankIDCodeCACPA BankIDCode = "CACPA" // CA routing number
+	BankIDCodeFR    BankIDCode = "FR"    // FR bank and branch code
+	BankIDCodeDEBLZ BankIDCode = "DEBLZ" // DE Bankleitzahl
+	BankIDCodeGRBIC BankIDCode = "GRBIC" // GR HEBIC
+	BankIDCodeHKNCC BankIDCode = "HKNCC" // HK bank code
+	BankIDCodeITNCC BankIDCode = "ITNCC" // IT ABI and CAB code
+	BankIDCodeLULUX BankIDCode = "LULUX" // LU bank code
+	BankIDCodePLKNR BankIDCode = "PLKNR" // PL bank and branch code
+	BankIDCodePTNCC BankIDCode = "PTNCC" // PT bank and branch code
+	BankIDCodeESNCC BankIDCode = "ESNCC" // ES bank and branch code
+	BankIDCodeCHBCC BankIDCode = "CHBCC" // CH bank clearing code
+	BankIDCodeUSABA BankIDCode = "USABA" // US ABA routing number
+)
+
+// Known reports whether c is one of the bank ID codes the API accepts
+func (c BankIDCode) Known() bool {
+	switch c {
+	case BankIDCodeGBDSC, BankIDCodeAUBSB, BankIDCodeBE, BankIDCodeCACPA, BankIDCodeFR,
+		BankIDCodeDEBLZ, BankIDCodeGRBIC, BankIDCodeHKNCC, BankIDCodeITNCC, BankIDCodeLULUX,
+		BankIDCodePLKNR, BankIDCodePTNCC, BankIDCodeESNCC, BankIDCodeCHBCC, BankIDCodeUSABA:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckEnums reports the enum attributes holding values unknown to this
+// client, as a *ValidationError. Empty values are not reported.
+// Unknown values are accepted by default so that values introduced by the
+// platform do not break older clients, see WithStrictEnums
+func (a *Attributes) CheckEnums() error {
+	var problems []FieldError
+	if a.AccountClassification != "" && !a.AccountClassification.Known() {
+		problems = append(problems, enumProblem("attributes.account_classification", string(a.AccountClassification)))
+	}
+	if a.Status != "" && !a.Status.Known() {
+		problems = append(problems, enumProblem("attributes.status", string(a.Status)))
+	}
+	if a.BankIDCode != "" && !a.BankIDCode.Known() {
+		problems = append(problems, enumProblem("attributes.bank_id_code", string(a.BankIDCode)))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func enumProblem(field, value string) FieldError {
+	return FieldError{Field: field, Message: fmt.Sprintf("unknown value %q", value)}
+}
+
+// UnmarshalStrict is json.Unmarshal rejecting unknown enum values in the
+// attributes held by v, with a *ValidationError. json.Unmarshal keeps them
+func UnmarshalStrict(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return CheckEnums(v)
+}
+
+// MarshalStrict is json.Marshal rejecting unknown enum values in the
+// attributes held by v, with a *ValidationError. json.Marshal keeps them
+func MarshalStrict(v interface{}) ([]byte, error) {
+	if err := CheckEnums(v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// CheckEnums is Attributes.CheckEnums for every Attributes held by v, such as
+// an *AccountCreate, an *AccountDTO or an *AccountListDTO. Fields are named
+// after their JSON path, e.g. data[2].attributes.status
+func CheckEnums(v interface{}) error {
+	var problems []FieldError
+	walkAttributes(reflect.ValueOf(v), "", func(path string, attr *Attributes) {
+		err, _ := attr.CheckEnums().(*ValidationError)
+		if err == nil {
+			return
+		}
+		for _, p := range err.Problems {
+			p.Field = path + p.Field
+			problems = append(problems, p)
+		}
+	})
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+var attributesType = reflect.TypeOf(Attributes{})
+
+// walkAttributes calls fn with every Attributes reachable from v. path is the
+// JSON path of v, fn gets the path of the object holding the attributes
+func walkAttributes(v reflect.Value, path string, fn func(path string, attr *Attributes)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkAttributes(v.Elem(), path, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkAttributes(v.Index(i), strings.TrimSuffix(path, ".")+"["+strconv.Itoa(i)+"].", fn)
+		}
+	case reflect.Struct:
+		if v.Type() == attributesType {
+			attr := v.Interface().(Attributes)
+			fn(strings.TrimSuffix(path, "attributes."), &attr)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			walkAttributes(v.Field(i), path+name+".", fn)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumsKnown(t *testing.T) {
+	assert.True(t, ClassificationPersonal.Known())
+	assert.True(t, ClassificationBusiness.Known())
+	assert.False(t, AccountClassification("bussiness").Known())
+	assert.False(t, AccountClassification("business").Known())
+	assert.False(t, AccountClassification("").Known())
+
+	for _, s := range []AccountStatus{StatusPending, StatusConfirmed, StatusFailed} {
+		assert.True(t, s.Known(), s)
+	}
+	assert.False(t, AccountStatus("Confirmed").Known())
+
+	for _, country := range SupportedCountries() {
+		rules, _ := RulesFor(country)
+		if rules.BankIDCode.Format != "" {
+			assert.True(t, BankIDCode(rules.BankIDCode.Format).Known(), country)
+		}
+	}
+	assert.False(t, BankIDCode("GBDCS").Known())
+}
+
+func TestEnumsLenientJSON(t *testing.T) {
+	data := `{"account_classification": "bussiness", "status": "closed", "bank_id_code": "XXNCC"}`
+
+	var attr Attributes
+	require.NoError(t, json.Unmarshal([]byte(data), &attr))
+
+	assert.Equal(t, AccountClassification("bussiness"), attr.AccountClassification)
+	assert.Equal(t, AccountStatus("closed"), attr.Status)
+	assert.Equal(t, BankIDCode("XXNCC"), attr.BankIDCode)
+
+	got, err := json.Marshal(&attr)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(got), "unknown values round-trip")
+}
+
+func TestCheckEnums(t *testing.T) {
+	assert.NoError(t, (&Attributes{}).CheckEnums())
+	assert.NoError(t, (&Attributes{
+		AccountClassification: ClassificationBusiness,
+		Status:                StatusPending,
+		BankIDCode:            BankIDCodeGBDSC,
+	}).CheckEnums())
+
+	err := (&Attributes{AccountClassification: "bussiness", Status: "closed", BankIDCode: "XXNCC"}).CheckEnums()
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"attributes.account_classification", "attributes.status", "attributes.bank_id_code",
+	}, validationErr.Fields())
+}
+
+func TestEnumsStrictJSON(t *testing.T) {
+	tests := map[string]struct {
+		data   string
+		v      interface{}
+		fields []string
+	}{
+		"attributes": {
+			data: `{"account_classification": "bussiness", "status": "pending"}`, v: &Attributes{},
+			fields: []string{"attributes.account_classification"},
+		},
+		"account create": {
+			data: `{"data": {"attributes": {"bank_id_code": "GBDCS"}}}`, v: &AccountCreateDTO{},
+			fields: []string{"data.attributes.bank_id_code"},
+		},
+		"account list": {
+			data: `{"data": [{"attributes": {"status": "pending"}}, {"attributes": {"status": "closed"}}]}`, v: &AccountListDTO{},
+			fields: []string{"data[1].attributes.status"},
+		},
+		"known values": {
+			data: `{"data": {"attributes": {"account_classification": "Business", "status": "confirmed"}}}`, v: &AccountDTO{},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := UnmarshalStrict([]byte(tc.data), tc.v)
+			if tc.fields == nil {
+				require.NoError(t, err)
+				_, err = MarshalStrict(tc.v)
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr), err)
+			assert.Equal(t, tc.fields, validationErr.Fields())
+
+			got, err := MarshalStrict(tc.v)
+			assert.Nil(t, got)
+			assert.True(t, errors.As(err, &validationErr), err)
+		})
+	}
+
+	var attr Attributes
+	assert.Error(t, UnmarshalStrict([]byte(`{"status": 1}`), &attr), "JSON errors are returned as they are")
+}
+
+func TestStrictEnumsResponses(t *testing.T) {
+	accountJSON := `{"type": "accounts", "id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", "attributes": {"status": "closed"}}`
+
+	tests := map[string]struct {
+		opts []Option
+		err  bool
+	}{
+		"lenient by default": {},
+		"strict":             {opts: []Option{WithStrictEnums()}, err: true},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			mock := client.MockClient{}
+			mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+				body := `{"data": ` + accountJSON + `}`
+				if req.URL.Query().Get("page[size]") != "" {
+					body = `{"data": [` + accountJSON + `]}`
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(body)),
+				}, nil
+			}
+
+			accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, tc.opts...)
+			require.NoError(t, err)
+
+			acc, err := accClient.Fetch(context.Background(), uuid.New())
+			page, listErr := accClient.List(context.Background(), &ListOptions{PageSize: 10})
+
+			if tc.err {
+				var validationErr *ValidationError
+				assert.True(t, errors.As(err, &validationErr))
+				assert.True(t, errors.As(listErr, &validationErr))
+				assert.Nil(t, acc)
+				assert.Nil(t, page)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, listErr)
+			assert.Equal(t, AccountStatus("closed"), acc.Attributes.Status)
+			assert.Equal(t, AccountStatus("closed"), page.Accounts[0].Attributes.Status)
+		})
+	}
+}
+
+func TestStrictEnumsRequests(t *testing.T) {
+	sent := 0
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"data": {}}`)),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{}, WithStrictEnums())
+	require.NoError(t, err)
+
+	acc := validAccountCreate()
+	acc.Attributes.Status = "closed"
+	_, err = accClient.Create(context.Background(), acc)
+	assert.Error(t, err)
+
+	_, err = accClient.Update(context.Background(), uuid.New(), 0, &AccountUpdate{
+		Attributes: &Attributes{Status: "closed"},
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, 0, sent, "requests with unknown values are not sent")
+
+	_, err = accClient.Update(context.Background(), uuid.New(), 0, &AccountUpdate{
+		Attributes: &Attributes{Status: StatusConfirmed},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestValidateRejectsMisspeltClassification(t *testing.T) {
+	acc := validAccountCreate()
+	acc.Attributes.AccountClassification = "bussiness"
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(acc.Validate(), &validationErr))
+	assert.Equal(t, []string{"attributes.account_classification"}, validationErr.Fields())
+}
@@ -0,0 +1,38 @@
+package accounts
+
+import (
+	"fmt"
+	"strings"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+)
+
+// VersionConflictError is returned by Update when the server rejects a change
+// with 409 Conflict because the given version is no longer the current version
+// of the account. The underlying client.APIError is available through errors.As
+type VersionConflictError struct {
+	ID      uuid.UUID
+	Version int
+	Err     *client.APIError
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: id=%s, version=%d: %v", e.ID, e.Version, e.Err)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// MismatchError is returned by CreateOrGet when an account with the requested ID
+// already exists but differs from the requested one. Fields lists the JSON names
+// of the differing fields, e.g. "attributes.country"
+type MismatchError struct {
+	ID     uuid.UUID
+	Fields []string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("account exists with different values: id=%s, fields=%s", e.ID, strings.Join(e.Fields, ","))
+}
@@ -0,0 +1,375 @@
+package accounts_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/banjoh/fake-api-client/fakeapi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests run the client against the in-process fake server, covering
+// the parts of the exchange canned responses cannot
+
+func newFakeClient(t *testing.T) (*accounts.Resource, *fakeapi.Server) {
+	srv := fakeapi.NewServer()
+	t.Cleanup(srv.Close)
+
+	accClient, err := accounts.New(
+		accounts.WithBaseURL(srv.URL),
+		accounts.WithHTTPClient(srv.Client()),
+		accounts.WithRetrySleeper(&client.MockRetrySleeper{}),
+	)
+	require.NoError(t, err)
+
+	return accClient, srv
+}
+
+func newGBAccount(t *testing.T, accountNumber string) *accounts.AccountCreate {
+	acc, err := accounts.NewGBAccount("400300", accountNumber).
+		OrganisationID(uuid.New()).
+		BIC("NWBKGB22").
+		Name("John Doe").
+		Build()
+	require.NoError(t, err)
+	return acc
+}
+
+func TestFakeAPILifecycle(t *testing.T) {
+	accClient, srv := newFakeClient(t)
+	ctx := context.Background()
+	accCreate := newGBAccount(t, "41426819")
+
+	created, err := accClient.Create(ctx, accCreate)
+	require.NoError(t, err)
+	assert.Equal(t, *accCreate.ID, *created.ID)
+	assert.Equal(t, 0, *created.Version)
+	require.NotNil(t, created.CreatedOn)
+	assert.False(t, created.CreatedOn.IsZero())
+	assert.Equal(t, accCreate.Attributes, created.Attributes)
+
+	fetched, err := accClient.Fetch(ctx, *accCreate.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, fetched)
+
+	updated, err := accClient.Update(ctx, *accCreate.ID, 0, &accounts.AccountUpdate{
+		Attributes: &accounts.Attributes{Status: accounts.StatusConfirmed},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, *updated.Version)
+	assert.Equal(t, accounts.StatusConfirmed, updated.Attributes.Status)
+	assert.Equal(t, "41426819", updated.Attributes.AccountNumber)
+
+	_, err = accClient.Update(ctx, *accCreate.ID, 0, &accounts.AccountUpdate{
+		Attributes: &accounts.Attributes{Status: accounts.StatusFailed},
+	})
+	var conflict *accounts.VersionConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, fakeapi.MsgInvalidVersion, conflict.Err.ErrorMessage)
+
+	err = accClient.Delete(ctx, *accCreate.ID, 0)
+	assert.ErrorIs(t, err, &client.APIError{StatusCode: http.StatusConflict, ErrorMessage: fakeapi.MsgInvalidVersion})
+
+	require.NoError(t, accClient.Delete(ctx, *accCreate.ID, 1))
+	assert.Equal(t, 0, srv.Len())
+
+	_, err = accClient.Fetch(ctx, *accCreate.ID)
+	assert.ErrorIs(t, err, &client.APIError{
+		StatusCode:   http.StatusNotFound,
+		ErrorMessage: fmt.Sprintf("record %s does not exist", accCreate.ID),
+	})
+
+	err = accClient.Delete(ctx, *accCreate.ID, 1)
+	assert.ErrorIs(t, err, &client.APIError{StatusCode: http.StatusNotFound})
+}
+
+func TestFakeAPIDuplicates(t *testing.T) {
+	accClient, _ := newFakeClient(t)
+	ctx := context.Background()
+	accCreate := newGBAccount(t, "41426819")
+
+	_, err := accClient.Create(ctx, accCreate)
+	require.NoError(t, err)
+
+	_, err = accClient.Create(ctx, accCreate)
+	assert.ErrorIs(t, err, &client.APIError{StatusCode: http.StatusConflict, ErrorMessage: fakeapi.MsgDuplicateAccount})
+
+	retried := newGBAccount(t, "11111111")
+	first, err := accClient.CreateWithIdempotencyKey(ctx, retried, "key-1")
+	require.NoError(t, err)
+	again, err := accClient.CreateWithIdempotencyKey(ctx, retried, "key-1")
+	require.NoError(t, err, "the same request with the same key is replayed")
+	assert.Equal(t, first, again)
+
+	existing, created, err := accClient.CreateOrGet(ctx, accCreate)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, *accCreate.ID, *existing.ID)
+
+	different := *accCreate
+	different.Attributes = newGBAccount(t, "22222222").Attributes
+	_, _, err = accClient.CreateOrGet(ctx, &different)
+	var mismatch *accounts.MismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, []string{"attributes.account_number"}, mismatch.Fields)
+}
+
+func TestFakeAPIListing(t *testing.T) {
+	accClient, _ := newFakeClient(t)
+	ctx := context.Background()
+
+	var ids []uuid.UUID
+	for i := 0; i < 7; i++ {
+		acc := newGBAccount(t, fmt.Sprintf("1000000%d", i))
+		if i%3 == 0 {
+			acc.Attributes.CustomerID = "vip"
+		}
+		_, err := accClient.Create(ctx, acc)
+		require.NoError(t, err)
+		ids = append(ids, *acc.ID)
+	}
+
+	page, err := accClient.List(ctx, &accounts.ListOptions{PageSize: 3})
+	require.NoError(t, err)
+
+	var listed []uuid.UUID
+	for {
+		for _, acc := range page.Accounts {
+			listed = append(listed, *acc.ID)
+		}
+		if !page.HasNext() {
+			break
+		}
+		page, err = accClient.NextPage(ctx, page)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, ids, listed)
+
+	_, err = accClient.NextPage(ctx, page)
+	assert.ErrorIs(t, err, accounts.ErrNoNextPage)
+
+	it := accClient.Iterate(ctx, &accounts.IteratorOptions{
+		ListOptions: accounts.ListOptions{PageSize: 2, Filter: accounts.NewFilter().CustomerID("vip")},
+		Prefetch:    true,
+	})
+	var iterated []uuid.UUID
+	for it.Next() {
+		iterated = append(iterated, *it.Account().ID)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []uuid.UUID{ids[0], ids[3], ids[6]}, iterated)
+
+	_, err = accClient.List(ctx, &accounts.ListOptions{PageSize: 1000})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func TestFakeAPIListingBehindPathPrefix(t *testing.T) {
+	srv := fakeapi.NewServer()
+	t.Cleanup(srv.Close)
+	gateway := httptest.NewServer(http.StripPrefix("/api", srv))
+	t.Cleanup(gateway.Close)
+
+	accClient, err := accounts.New(
+		accounts.WithBaseURL(gateway.URL+"/api"),
+		accounts.WithHTTPClient(gateway.Client()),
+		accounts.WithRetrySleeper(&client.MockRetrySleeper{}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		acc := newGBAccount(t, fmt.Sprintf("2000000%d", i))
+		_, err := accClient.Create(ctx, acc)
+		require.NoError(t, err)
+		ids = append(ids, *acc.ID)
+	}
+
+	it := accClient.Iterate(ctx, &accounts.IteratorOptions{ListOptions: accounts.ListOptions{PageSize: 1}})
+	var iterated []uuid.UUID
+	for it.Next() {
+		iterated = append(iterated, *it.Account().ID)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, ids, iterated)
+}
+
+func TestFakeAPIUpdateWithRefetch(t *testing.T) {
+	accClient, _ := newFakeClient(t)
+	ctx := context.Background()
+	accCreate := newGBAccount(t, "41426819")
+
+	_, err := accClient.Create(ctx, accCreate)
+	require.NoError(t, err)
+
+	for _, status := range []accounts.AccountStatus{accounts.StatusPending, accounts.StatusConfirmed} {
+		_, err := accClient.UpdateWithRefetch(ctx, *accCreate.ID, &accounts.AccountUpdate{
+			Attributes: &accounts.Attributes{Status: status},
+		}, 3)
+		require.NoError(t, err)
+	}
+
+	acc, err := accClient.Fetch(ctx, *accCreate.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *acc.Version)
+	assert.Equal(t, accounts.StatusConfirmed, acc.Attributes.Status)
+}
+
+func TestFakeAPIFaults(t *testing.T) {
+	tests := map[string]struct {
+		rule     fakeapi.FaultRule
+		timeout  time.Duration
+		requests int
+		err      error
+		check    func(t *testing.T, err error)
+	}{
+		"5xx burst": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Nth: fakeapi.Requests(1, 4), Fault: fakeapi.ErrorFault(503)},
+			requests: 5,
+		},
+		"5xx outage": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Fault: fakeapi.ErrorFault(500)},
+			requests: 5,
+			err:      &client.APIError{StatusCode: 500, ErrorMessage: "internal server error"},
+		},
+		"connection reset": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Nth: []int{1}, Fault: fakeapi.ResetFault()},
+			requests: 2,
+		},
+		"slow response": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Nth: []int{1}, Fault: fakeapi.DelayFault(time.Minute)},
+			timeout:  100 * time.Millisecond,
+			requests: 2,
+		},
+		"truncated body": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Fault: fakeapi.TruncateFault()},
+			requests: 1,
+			check: func(t *testing.T, err error) {
+				var syntaxErr *json.SyntaxError
+				assert.True(t, errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF), err)
+			},
+		},
+		"empty error body": {
+			rule:     fakeapi.FaultRule{Route: fakeapi.RouteFetch, Fault: fakeapi.EmptyBodyFault(400)},
+			requests: 1,
+			err:      &client.APIError{StatusCode: 400},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			srv := fakeapi.NewServer()
+			t.Cleanup(srv.Close)
+
+			httpClient := srv.Client()
+			httpClient.Timeout = tc.timeout
+			accClient, err := accounts.New(
+				accounts.WithBaseURL(srv.URL),
+				accounts.WithHTTPClient(httpClient),
+				accounts.WithRetrySleeper(&client.MockRetrySleeper{}),
+			)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			accCreate := newGBAccount(t, "41426819")
+			_, err = accClient.Create(ctx, accCreate)
+			require.NoError(t, err)
+
+			srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{tc.rule}})
+			acc, err := accClient.Fetch(ctx, *accCreate.ID)
+
+			assert.Equal(t, tc.requests, srv.Requests(fakeapi.RouteFetch))
+			switch {
+			case tc.check != nil:
+				assert.Nil(t, acc)
+				tc.check(t, err)
+			case tc.err != nil:
+				assert.Nil(t, acc)
+				assert.ErrorIs(t, err, tc.err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, *accCreate.ID, *acc.ID)
+			}
+		})
+	}
+}
+
+func TestFakeAPIRateLimited(t *testing.T) {
+	srv := fakeapi.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{
+		{Route: fakeapi.RouteList, Nth: fakeapi.Requests(1, 2), Fault: fakeapi.RateLimitFault(3 * time.Second)},
+	}})
+
+	sleeper := &client.MockRetrySleeper{}
+	accClient, err := accounts.New(
+		accounts.WithBaseURL(srv.URL),
+		accounts.WithHTTPClient(srv.Client()),
+		accounts.WithRetrySleeper(sleeper),
+	)
+	require.NoError(t, err)
+
+	page, err := accClient.List(context.Background(), nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, page.Accounts)
+	assert.Equal(t, 3, srv.Requests(fakeapi.RouteList))
+	assert.Equal(t, []time.Duration{3 * time.Second, 3 * time.Second}, sleeper.Slept)
+
+	srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{
+		{Route: fakeapi.RouteList, Fault: fakeapi.RateLimitFault(3 * time.Second)},
+	}})
+
+	_, err = accClient.List(context.Background(), nil)
+
+	var rateLimited *client.RateLimitedError
+	require.True(t, errors.As(err, &rateLimited))
+	assert.Equal(t, 3*time.Second, rateLimited.RateLimit.RetryAfter)
+}
+
+func TestFakeAPICreateSurvivesFaults(t *testing.T) {
+	srv := fakeapi.NewServer()
+	t.Cleanup(srv.Close)
+
+	// The reset and the 502 are retried. The third attempt creates the account
+	// but its response is cut short, leaving CreateOrGet to find it
+	srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{
+		{Route: fakeapi.RouteCreate, Nth: []int{1}, Fault: fakeapi.ResetFault()},
+		{Route: fakeapi.RouteCreate, Nth: []int{2}, Fault: fakeapi.ErrorFault(502)},
+		{Route: fakeapi.RouteCreate, Nth: []int{3}, Fault: fakeapi.TruncateFault()},
+	}})
+
+	accClient, err := accounts.New(
+		accounts.WithBaseURL(srv.URL),
+		accounts.WithHTTPClient(srv.Client()),
+		accounts.WithRetrySleeper(&client.MockRetrySleeper{}),
+	)
+	require.NoError(t, err)
+
+	accCreate := newGBAccount(t, "41426819")
+	_, err = accClient.Create(context.Background(), accCreate)
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, srv.Requests(fakeapi.RouteCreate))
+	assert.Equal(t, 1, srv.Len())
+
+	acc, created, err := accClient.CreateOrGet(context.Background(), accCreate)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, *accCreate.ID, *acc.ID)
+	assert.Equal(t, 1, srv.Len())
+}
@@ -0,0 +1,81 @@
+package accounts
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Filter narrows the accounts returned by List down to those whose attributes
+// match. Each filterable attribute has its own setter, so a misspelt or
+// unsupported field is a compile error rather than a silently empty result.
+// Setting several values for the same attribute matches any of them
+//
+//	filter := accounts.NewFilter().Country("GB").BankID("400300")
+//	page, err := resource.List(ctx, &accounts.ListOptions{Filter: filter})
+type Filter struct {
+	values map[string][]string
+}
+
+// NewFilter returns an empty filter matching every account
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// BankID matches accounts by Attributes.BankID
+func (f *Filter) BankID(ids ...string) *Filter {
+	return f.set("bank_id", ids)
+}
+
+// BankIDCode matches accounts by Attributes.BankIDCode
+func (f *Filter) BankIDCode(codes ...BankIDCode) *Filter {
+	values := make([]string, 0, len(codes))
+	for _, c := range codes {
+		values = append(values, string(c))
+	}
+	return f.set("bank_id_code", values)
+}
+
+// AccountNumber matches accounts by Attributes.AccountNumber
+func (f *Filter) AccountNumber(numbers ...string) *Filter {
+	return f.set("account_number", numbers)
+}
+
+// IBAN matches accounts by Attributes.IBAN
+func (f *Filter) IBAN(ibans ...string) *Filter {
+	return f.set("iban", ibans)
+}
+
+// CustomerID matches accounts by Attributes.CustomerID
+func (f *Filter) CustomerID(ids ...string) *Filter {
+	return f.set("customer_id", ids)
+}
+
+// Country matches accounts by Attributes.Country
+func (f *Filter) Country(countries ...string) *Filter {
+	return f.set("country", countries)
+}
+
+func (f *Filter) set(field string, values []string) *Filter {
+	if f.values == nil {
+		f.values = map[string][]string{}
+	}
+
+	f.values[field] = append(f.values[field], values...)
+
+	return f
+}
+
+// encode adds the filter to q as filter[<attribute>] query parameters
+func (f *Filter) encode(q url.Values) {
+	if f == nil {
+		return
+	}
+
+	for field, values := range f.values {
+		if len(values) == 0 {
+			continue
+		}
+		q.Set(fmt.Sprintf("filter[%s]", field), strings.Join(values, ","))
+	}
+}
@@ -0,0 +1,97 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterEncode(t *testing.T) {
+	tests := map[string]struct {
+		filter *Filter
+		want   url.Values
+	}{
+		"nil":   {filter: nil, want: url.Values{}},
+		"empty": {filter: NewFilter(), want: url.Values{}},
+		"single values": {
+			filter: NewFilter().Country("GB").BankID("400300").AccountNumber("41426819"),
+			want: url.Values{
+				"filter[country]":        {"GB"},
+				"filter[bank_id]":        {"400300"},
+				"filter[account_number]": {"41426819"},
+			},
+		},
+		"multiple values": {
+			filter: NewFilter().Country("GB", "DE").Country("FR").IBAN("GB11NWBK40030041426819"),
+			want: url.Values{
+				"filter[country]": {"GB,DE,FR"},
+				"filter[iban]":    {"GB11NWBK40030041426819"},
+			},
+		},
+		"zero value": {
+			filter: (&Filter{}).CustomerID("cust-1").BankIDCode("GBDSC"),
+			want: url.Values{
+				"filter[customer_id]":  {"cust-1"},
+				"filter[bank_id_code]": {"GBDSC"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got := url.Values{}
+			tc.filter.encode(got)
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFilterFieldsExistOnAttributes(t *testing.T) {
+	tags := map[string]bool{}
+	typ := reflect.TypeOf(Attributes{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		tags[name] = true
+	}
+
+	f := NewFilter().BankID("a").BankIDCode("a").AccountNumber("a").IBAN("a").CustomerID("a").Country("a")
+
+	for field := range f.values {
+		assert.Truef(t, tags[field], "filter field %q is not an account attribute", field)
+	}
+}
+
+func TestListAccountsWithFilter(t *testing.T) {
+	var gotReq *http.Request
+	mock := client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"data": []}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(&mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	opts := &ListOptions{PageSize: 10, Filter: NewFilter().Country("GB").BankID("400300", "400301")}
+	_, err = accClient.List(context.Background(), opts)
+
+	require.NoError(t, err)
+	q := gotReq.URL.Query()
+	assert.Equal(t, "GB", q.Get("filter[country]"))
+	assert.Equal(t, "400300,400301", q.Get("filter[bank_id]"))
+	assert.Equal(t, "10", q.Get("page[size]"))
+}
@@ -0,0 +1,166 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+)
+
+// IteratorOptions configures how an Iterator walks the account pages
+type IteratorOptions struct {
+	// ListOptions selects the page the iteration starts from and the page size
+	ListOptions
+
+	// Prefetch requests the following page in the background while the
+	// accounts of the current page are being consumed
+	Prefetch bool
+}
+
+// Iterator walks every account returned by List, transparently following
+// the `next` pagination links until the last page. An Iterator is not safe
+// for concurrent use.
+//
+//	it := resource.Iterate(ctx, nil)
+//	for it.Next() {
+//		acc := it.Account()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	ctx  context.Context
+	r    *Resource
+	opts IteratorOptions
+
+	page    *AccountPage
+	idx     int
+	acc     *Account
+	err     error
+	started bool
+	done    bool
+
+	// seen holds every `next` link followed so far. A server handing out a
+	// link twice would otherwise have the iterator loop forever
+	seen    map[string]bool
+	pending chan pageResult
+}
+
+type pageResult struct {
+	page *AccountPage
+	err  error
+}
+
+// Iterate returns an Iterator over all accounts. A nil opts starts at the
+// first page with the server's default page size and no prefetching
+func (r *Resource) Iterate(ctx context.Context, opts *IteratorOptions) *Iterator {
+	it := &Iterator{
+		ctx:  ctx,
+		r:    r,
+		seen: map[string]bool{},
+	}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if ctx == nil {
+		it.err = fmt.Errorf("accounts.Iterate: nil Context")
+	}
+
+	return it
+}
+
+// Next advances the iterator to the following account, fetching the next
+// page when the current one is exhausted. It returns false once all accounts
+// have been visited or an error occurred, which is then reported by Err
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+
+	// Pages may legitimately be empty while still linking to a next page
+	for it.page == nil || it.idx >= len(it.page.Accounts) {
+		if !it.advance() {
+			it.acc = nil
+			return false
+		}
+	}
+
+	it.acc = &it.page.Accounts[it.idx]
+	it.idx++
+
+	return true
+}
+
+// Account returns the account the iterator currently points at.
+// It is nil before the first call to Next and after iteration ends
+func (it *Iterator) Account() *Account {
+	return it.acc
+}
+
+// Err returns the error that stopped the iteration, if any.
+// Reaching the last page is not an error
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) advance() bool {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	var res pageResult
+	if !it.started {
+		it.started = true
+		res.page, res.err = it.r.List(it.ctx, &it.opts.ListOptions)
+	} else {
+		if !it.page.HasNext() {
+			it.done = true
+			return false
+		}
+
+		next := it.pending
+		it.pending = nil
+		if next == nil {
+			next = it.fetch(it.page)
+		}
+
+		select {
+		case res = <-next:
+		case <-it.ctx.Done():
+			res.err = it.ctx.Err()
+		}
+	}
+
+	if res.err != nil {
+		it.err = res.err
+		return false
+	}
+
+	it.page = res.page
+	it.idx = 0
+
+	if it.opts.Prefetch && it.page.HasNext() {
+		it.pending = it.fetch(it.page)
+	}
+
+	return true
+}
+
+// fetch requests the page following page in the background. The channel is
+// buffered so the goroutine never leaks when the iterator is abandoned
+func (it *Iterator) fetch(page *AccountPage) chan pageResult {
+	ch := make(chan pageResult, 1)
+
+	next := page.Links.Next
+	if it.seen[next] {
+		ch <- pageResult{err: fmt.Errorf("accounts.Iterator: pagination loop detected: next=%s", next)}
+		return ch
+	}
+	it.seen[next] = true
+
+	go func() {
+		p, err := it.r.NextPage(it.ctx, page)
+		ch <- pageResult{page: p, err: err}
+	}()
+
+	return ch
+}
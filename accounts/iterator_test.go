@@ -0,0 +1,182 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedMock serves the given pages keyed by their page[number] query parameter.
+// Every page links to the following one, except for the last
+func pagedMock(t *testing.T, pages [][]uuid.UUID) (*client.MockClient, func() []string) {
+	var mu sync.Mutex
+	var requested []string
+
+	mock := &client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		number := req.URL.Query().Get("page[number]")
+		if number == "" {
+			number = "0"
+		}
+
+		mu.Lock()
+		requested = append(requested, number)
+		mu.Unlock()
+
+		var n int
+		_, err := fmt.Sscanf(number, "%d", &n)
+		require.NoError(t, err)
+
+		data := make([]string, 0, len(pages[n]))
+		for _, id := range pages[n] {
+			data = append(data, fmt.Sprintf(`{"type": "accounts", "id": "%s"}`, id))
+		}
+
+		next := ""
+		if n+1 < len(pages) {
+			next = fmt.Sprintf("/v1/organisation/accounts?page%%5Bnumber%%5D=%d", n+1)
+		}
+
+		body := fmt.Sprintf(`{"data": [%s], "links": {"next": "%s"}}`, strings.Join(data, ","), next)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+
+	return mock, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func collect(it *Iterator) []uuid.UUID {
+	var ids []uuid.UUID
+	for it.Next() {
+		ids = append(ids, *it.Account().ID)
+	}
+	return ids
+}
+
+func TestIteratorWalksAllPages(t *testing.T) {
+	pages := [][]uuid.UUID{
+		{uuid.New(), uuid.New()},
+		{},
+		{uuid.New()},
+		{uuid.New(), uuid.New()},
+	}
+	var want []uuid.UUID
+	for _, p := range pages {
+		want = append(want, p...)
+	}
+
+	for _, prefetch := range []bool{false, true} {
+		prefetch := prefetch
+		t.Run(fmt.Sprintf("prefetch=%t", prefetch), func(t *testing.T) {
+			mock, requested := pagedMock(t, pages)
+			accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+			require.NoError(t, err)
+
+			it := accClient.Iterate(context.Background(), &IteratorOptions{Prefetch: prefetch})
+
+			assert.Equal(t, want, collect(it))
+			assert.NoError(t, it.Err())
+			assert.Nil(t, it.Account())
+			assert.False(t, it.Next())
+			assert.Equal(t, []string{"0", "1", "2", "3"}, requested())
+		})
+	}
+}
+
+func TestIteratorStartPage(t *testing.T) {
+	pages := [][]uuid.UUID{{uuid.New()}, {uuid.New()}, {uuid.New()}}
+	mock, requested := pagedMock(t, pages)
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	opts := &IteratorOptions{ListOptions: ListOptions{PageNumber: 1}}
+	it := accClient.Iterate(context.Background(), opts)
+
+	assert.Equal(t, []uuid.UUID{pages[1][0], pages[2][0]}, collect(it))
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"1", "2"}, requested())
+}
+
+func TestIteratorContextCancelledBetweenPages(t *testing.T) {
+	pages := [][]uuid.UUID{{uuid.New()}, {uuid.New()}, {uuid.New()}}
+	mock, requested := pagedMock(t, pages)
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := accClient.Iterate(ctx, nil)
+
+	require.True(t, it.Next())
+	assert.Equal(t, pages[0][0], *it.Account().ID)
+
+	cancel()
+
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+	assert.Equal(t, []string{"0"}, requested())
+}
+
+func TestIteratorDetectsPaginationLoop(t *testing.T) {
+	mock := &client.MockClient{}
+	calls := 0
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		calls++
+		body := `{"data": [{"type": "accounts"}], "links": {"next": "/v1/organisation/accounts?page%5Bnumber%5D=1"}}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	it := accClient.Iterate(context.Background(), nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+
+	assert.Error(t, it.Err())
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIteratorStopsOnError(t *testing.T) {
+	mock := &client.MockClient{}
+	mock.DoImpl = func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"error_message": "invalid page"}`))),
+		}, nil
+	}
+
+	accClient, err := NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	it := accClient.Iterate(context.Background(), nil)
+
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), &client.APIError{
+		ErrorMessage: "invalid page",
+		StatusCode:   http.StatusBadRequest,
+	})
+}
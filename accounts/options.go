@@ -0,0 +1,137 @@
+package accounts
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures a Resource when passed to New. Options validate
+// their arguments and make New fail rather than produce a broken client
+type Option func(r *Resource) error
+
+// WithBaseURL sets the root URL of the API the resource sends requests to,
+// e.g. https://api.staging.example.com. Only http and https are supported
+func WithBaseURL(baseURL string) Option {
+	return func(r *Resource) error {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("accounts.WithBaseURL: invalid url: %w", err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("accounts.WithBaseURL: unsupported scheme: %q", baseURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("accounts.WithBaseURL: missing host: %q", baseURL)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("accounts.WithBaseURL: unexpected query or fragment: %q", baseURL)
+		}
+
+		// Resource paths are appended with a separating slash
+		r.BaseURL = strings.TrimRight(baseURL, "/")
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http client requests are sent with
+func WithHTTPClient(c client.HTTPClient) Option {
+	return func(r *Resource) error {
+		if c == nil {
+			return fmt.Errorf("accounts.WithHTTPClient: nil client.HTTPClient")
+		}
+
+		r.client = c
+		return nil
+	}
+}
+
+// WithRetrySleeper sets the sleeper used to wait between retried requests
+func WithRetrySleeper(s client.RetrySleeper) Option {
+	return func(r *Resource) error {
+		if s == nil {
+			return fmt.Errorf("accounts.WithRetrySleeper: nil client.RetrySleeper")
+		}
+
+		r.retrySleeper = s
+		return nil
+	}
+}
+
+// WithRetryPolicy sets how often and how long apart idempotent requests are
+// retried, overriding the MaxAttempts and BaseDelay of the resource's
+// client.RetryPolicy. A count of 0 disables retries
+func WithRetryPolicy(count int, delay time.Duration) Option {
+	return func(r *Resource) error {
+		if count < 0 {
+			return fmt.Errorf("accounts.WithRetryPolicy: negative count: %d", count)
+		}
+		if delay < 0 {
+			return fmt.Errorf("accounts.WithRetryPolicy: negative delay: %s", delay)
+		}
+
+		r.retryPolicy.MaxAttempts = count
+		r.retryPolicy.BaseDelay = delay
+		if r.retryPolicy.MaxDelay > 0 && delay > r.retryPolicy.MaxDelay {
+			r.retryPolicy.MaxDelay = delay
+		}
+		return nil
+	}
+}
+
+// WithRetry sets how idempotent requests of this resource are retried.
+// client.DefaultRetryPolicy is used by default
+func WithRetry(p client.RetryPolicy) Option {
+	return func(r *Resource) error {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("accounts.WithRetry: %w", err)
+		}
+
+		// Copy the status codes so that later changes by the caller
+		// to their slice do not leak into the resource
+		p.RetryableStatusCodes = append([]int(nil), p.RetryableStatusCodes...)
+		r.retryPolicy = p
+		return nil
+	}
+}
+
+// WithLogger sets the logger diagnostics such as retries are reported to.
+// The logrus standard logger is used by default
+func WithLogger(l logrus.FieldLogger) Option {
+	return func(r *Resource) error {
+		if l == nil {
+			return fmt.Errorf("accounts.WithLogger: nil logrus.FieldLogger")
+		}
+
+		r.logger = l
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(ua string) Option {
+	return func(r *Resource) error {
+		if strings.TrimSpace(ua) == "" {
+			return fmt.Errorf("accounts.WithUserAgent: empty user agent")
+		}
+
+		r.userAgent = ua
+		return nil
+	}
+}
+
+// WithStrictEnums makes the resource reject enum attributes holding values
+// unknown to this client, such as a misspelt account classification, both in
+// the accounts it sends and in the accounts it receives. By default unknown
+// values are kept as they are, see Attributes.CheckEnums
+func WithStrictEnums() Option {
+	return func(r *Resource) error {
+		r.strictEnums = true
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDefaults(t *testing.T) {
+	r, err := New()
+
+	require.NoError(t, err)
+	assert.Equal(t, defultBaseURL, r.BaseURL)
+	assert.Equal(t, client.DefaultClient, r.client)
+	assert.Equal(t, defaultUserAgent, r.userAgent)
+	assert.Equal(t, client.DefaultRetryPolicy(), r.retryPolicy)
+}
+
+func TestNewWithOptions(t *testing.T) {
+	mock := &client.MockClient{}
+	sleeper := &client.MockRetrySleeper{}
+	logger, _ := test.NewNullLogger()
+
+	r, err := New(
+		WithBaseURL("https://api.example.com/"),
+		WithHTTPClient(mock),
+		WithRetrySleeper(sleeper),
+		WithRetryPolicy(3, time.Second),
+		WithLogger(logger),
+		WithUserAgent("my-service/1.0"),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", r.BaseURL)
+	assert.Same(t, mock, r.client)
+	assert.Same(t, sleeper, r.retrySleeper)
+	assert.Equal(t, 3, r.retryPolicy.MaxAttempts)
+	assert.Equal(t, time.Second, r.retryPolicy.BaseDelay)
+	assert.Same(t, logger, r.logger)
+	assert.Equal(t, "my-service/1.0", r.userAgent)
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	tests := map[string]Option{
+		"relative base url":    WithBaseURL("/v1"),
+		"unsupported scheme":   WithBaseURL("ftp://example.com"),
+		"unparsable base url":  WithBaseURL("http://exa mple.com:port"),
+		"base url with query":  WithBaseURL("http://example.com?x=1"),
+		"nil http client":      WithHTTPClient(nil),
+		"nil retry sleeper":    WithRetrySleeper(nil),
+		"negative retry count": WithRetryPolicy(-1, time.Second),
+		"negative retry delay": WithRetryPolicy(1, -time.Second),
+		"invalid retry policy": WithRetry(client.RetryPolicy{MaxAttempts: -1}),
+		"nil logger":           WithLogger(nil),
+		"empty user agent":     WithUserAgent(" "),
+	}
+
+	for name, opt := range tests {
+		opt := opt
+		t.Run(name, func(t *testing.T) {
+			r, err := New(opt)
+
+			assert.Error(t, err)
+			assert.Nil(t, r)
+		})
+	}
+}
+
+func TestOptionsApplyToRequests(t *testing.T) {
+	var gotReq *http.Request
+	calls := 0
+	mock := &client.MockClient{}
+	mock.DoImpl = func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	r, err := NewWithClient(mock, &client.MockRetrySleeper{},
+		WithBaseURL("https://api.example.com"),
+		WithRetryPolicy(2, time.Millisecond),
+		WithLogger(logger),
+		WithUserAgent("my-service/1.0"),
+	)
+	require.NoError(t, err)
+
+	id := uuid.New()
+	_, err = r.Fetch(context.Background(), id)
+
+	assert.Error(t, err)
+	assert.Equal(t, "https://api.example.com/v1/organisation/accounts/"+id.String(), gotReq.URL.String())
+	assert.Equal(t, "my-service/1.0", gotReq.Header.Get("User-Agent"))
+	assert.Equal(t, 2, calls)
+	assert.Len(t, hook.AllEntries(), 1)
+}
+
+func TestWithRetry(t *testing.T) {
+	codes := []int{http.StatusServiceUnavailable}
+	r, err := New(WithRetry(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, RetryableStatusCodes: codes}))
+	require.NoError(t, err)
+
+	codes[0] = http.StatusBadGateway
+	assert.Equal(t, client.RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Second,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}, r.retryPolicy)
+
+	r, err = New(WithRetry(client.NoRetryPolicy()), WithRetryPolicy(4, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, client.RetryPolicy{MaxAttempts: 4, BaseDelay: time.Minute}, r.retryPolicy)
+}
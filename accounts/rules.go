@@ -0,0 +1,304 @@
+package accounts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/banjoh/fake-api-client/iban"
+)
+
+// Requirement tells whether a field must, may or must not be set
+type Requirement int
+
+const (
+	Optional Requirement = iota
+	Required
+	Forbidden
+)
+
+func (r Requirement) String() string {
+	switch r {
+	case Optional:
+		return "optional"
+	case Required:
+		return "required"
+	case Forbidden:
+		return "forbidden"
+	default:
+		return fmt.Sprintf("Requirement(%d)", int(r))
+	}
+}
+
+// FieldRule is the rule a single attribute follows in a given country.
+// Format describes the expected values, e.g. "6 digit sort code"
+type FieldRule struct {
+	Requirement Requirement
+	Format      string
+
+	pattern  *regexp.Regexp
+	validate func(value string) error
+}
+
+// CountryRules are the rules the platform applies to the bank details of
+// accounts held in a country
+type CountryRules struct {
+	BankID        FieldRule
+	BankIDCode    FieldRule
+	BIC           FieldRule
+	AccountNumber FieldRule
+	IBAN          FieldRule
+
+	// extra checks the rules involving several fields
+	extra func(attr *Attributes) []FieldError
+}
+
+var bicRule = FieldRule{
+	Format:   "8 or 11 character BIC",
+	pattern:  regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`),
+	validate: iban.ValidateBIC,
+}
+
+func required(r FieldRule) FieldRule {
+	r.Requirement = Required
+	return r
+}
+
+func forbidden() FieldRule {
+	return FieldRule{Requirement: Forbidden}
+}
+
+func digits(n int, name string) FieldRule {
+	return FieldRule{Format: fmt.Sprintf("%d digit %s", n, name), pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9]{%d}$`, n))}
+}
+
+func digitRange(min, max int, name string) FieldRule {
+	return FieldRule{
+		Format:  fmt.Sprintf("%d to %d digit %s", min, max, name),
+		pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9]{%d,%d}$`, min, max)),
+	}
+}
+
+func alphanumeric(n int, name string) FieldRule {
+	return FieldRule{Format: fmt.Sprintf("%d character %s", n, name), pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9A-Z]{%d}$`, n))}
+}
+
+func bankIDCode(code BankIDCode) FieldRule {
+	return FieldRule{Format: string(code), pattern: regexp.MustCompile("^" + string(code) + "$")}
+}
+
+// ibanRule describes the IBAN of a country, country code and check digits
+// included in its length. The structure and check digits are checked as well
+func ibanRule(country string, length int) FieldRule {
+	return FieldRule{
+		Format:   fmt.Sprintf("%d character %s IBAN", length, country),
+		pattern:  regexp.MustCompile(fmt.Sprintf(`^%s[0-9]{2}[0-9A-Z]{%d}$`, country, length-4)),
+		validate: iban.Validate,
+	}
+}
+
+// countryRules holds the rules of every supported country. Fields left out of
+// a country's rules, or without a format, are optional and not checked
+var countryRules = map[string]CountryRules{
+	"GB": {
+		BankID:        required(digits(6, "sort code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeGBDSC)),
+		BIC:           required(bicRule),
+		AccountNumber: digits(8, "account number"),
+		IBAN:          ibanRule("GB", 22),
+	},
+	"AU": {
+		BankID:     digits(6, "BSB code"),
+		BankIDCode: required(bankIDCode(BankIDCodeAUBSB)),
+		BIC:        required(bicRule),
+		AccountNumber: FieldRule{
+			Format:  "6 to 10 digit account number not starting with 0",
+			pattern: regexp.MustCompile(`^[1-9][0-9]{5,9}$`),
+		},
+		IBAN: forbidden(),
+	},
+	"BE": {
+		BankID:        required(digits(3, "bank code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeBE)),
+		BIC:           bicRule,
+		AccountNumber: digits(7, "account number"),
+		IBAN:          ibanRule("BE", 16),
+	},
+	"CA": {
+		BankID: FieldRule{
+			Format:  "9 digit routing number starting with 0",
+			pattern: regexp.MustCompile(`^0[0-9]{8}$`),
+		},
+		BankIDCode:    bankIDCode(BankIDCodeCACPA),
+		BIC:           required(bicRule),
+		AccountNumber: digitRange(7, 12, "account number"),
+		IBAN:          forbidden(),
+	},
+	"FR": {
+		BankID:        required(alphanumeric(10, "bank and branch code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeFR)),
+		BIC:           bicRule,
+		AccountNumber: alphanumeric(11, "account number"),
+		IBAN:          ibanRule("FR", 27),
+	},
+	"DE": {
+		BankID:        required(digits(8, "BLZ")),
+		BankIDCode:    required(bankIDCode(BankIDCodeDEBLZ)),
+		BIC:           bicRule,
+		AccountNumber: digitRange(1, 10, "account number"),
+		IBAN:          ibanRule("DE", 22),
+	},
+	"GR": {
+		BankID:        required(digits(7, "HEBIC")),
+		BankIDCode:    required(bankIDCode(BankIDCodeGRBIC)),
+		BIC:           bicRule,
+		AccountNumber: digits(16, "account number"),
+		IBAN:          ibanRule("GR", 27),
+	},
+	"HK": {
+		BankID:        digits(3, "bank code"),
+		BankIDCode:    bankIDCode(BankIDCodeHKNCC),
+		BIC:           required(bicRule),
+		AccountNumber: digitRange(9, 12, "account number"),
+		IBAN:          forbidden(),
+	},
+	"IT": {
+		// The bank ID holds the national check character as well,
+		// making it 11 characters long, when no account number is given
+		BankID: required(FieldRule{
+			Format:  "10 or 11 character ABI and CAB code",
+			pattern: regexp.MustCompile(`^[0-9A-Z]{10,11}$`),
+		}),
+		BankIDCode:    required(bankIDCode(BankIDCodeITNCC)),
+		BIC:           bicRule,
+		AccountNumber: alphanumeric(12, "account number"),
+		IBAN:          ibanRule("IT", 27),
+		extra:         checkITBankID,
+	},
+	"LU": {
+		BankID:        required(digits(3, "bank code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeLULUX)),
+		BIC:           bicRule,
+		AccountNumber: alphanumeric(13, "account number"),
+		IBAN:          ibanRule("LU", 20),
+	},
+	"NL": {
+		BankID:        forbidden(),
+		BankIDCode:    forbidden(),
+		BIC:           required(bicRule),
+		AccountNumber: digitRange(1, 10, "account number"),
+		IBAN:          ibanRule("NL", 18),
+	},
+	"PL": {
+		BankID:        required(digits(8, "bank and branch code")),
+		BankIDCode:    required(bankIDCode(BankIDCodePLKNR)),
+		BIC:           bicRule,
+		AccountNumber: digits(16, "account number"),
+		IBAN:          ibanRule("PL", 28),
+	},
+	"PT": {
+		BankID:        required(digits(8, "bank and branch code")),
+		BankIDCode:    required(bankIDCode(BankIDCodePTNCC)),
+		BIC:           bicRule,
+		AccountNumber: digits(11, "account number"),
+		IBAN:          ibanRule("PT", 25),
+	},
+	"ES": {
+		BankID:        required(digits(8, "bank and branch code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeESNCC)),
+		BIC:           bicRule,
+		AccountNumber: digits(10, "account number"),
+		IBAN:          ibanRule("ES", 24),
+	},
+	"CH": {
+		BankID:        required(digits(5, "bank clearing code")),
+		BankIDCode:    required(bankIDCode(BankIDCodeCHBCC)),
+		BIC:           bicRule,
+		AccountNumber: alphanumeric(12, "account number"),
+		IBAN:          ibanRule("CH", 21),
+	},
+	"US": {
+		BankID:        required(digits(9, "ABA routing number")),
+		BankIDCode:    required(bankIDCode(BankIDCodeUSABA)),
+		BIC:           required(bicRule),
+		AccountNumber: digitRange(6, 17, "account number"),
+		IBAN:          forbidden(),
+	},
+}
+
+// RulesFor returns the rules of the given country. ok is false for countries
+// the platform does not support
+func RulesFor(country string) (rules CountryRules, ok bool) {
+	rules, ok = countryRules[country]
+	return rules, ok
+}
+
+// SupportedCountries returns the codes of the countries RulesFor knows about
+func SupportedCountries() []string {
+	countries := make([]string, 0, len(countryRules))
+	for c := range countryRules {
+		countries = append(countries, c)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// check reports the problems of attr against the country's rules
+func (rules CountryRules) check(country string, attr *Attributes) []FieldError {
+	var problems []FieldError
+	for _, f := range []struct {
+		field string
+		value string
+		rule  FieldRule
+	}{
+		{"attributes.bank_id", attr.BankID, rules.BankID},
+		{"attributes.bank_id_code", string(attr.BankIDCode), rules.BankIDCode},
+		{"attributes.bic", attr.BIC, rules.BIC},
+		{"attributes.account_number", attr.AccountNumber, rules.AccountNumber},
+		{"attributes.iban", attr.IBAN, rules.IBAN},
+	} {
+		if msg := f.rule.check(country, f.value); msg != "" {
+			problems = append(problems, FieldError{Field: f.field, Message: msg})
+		}
+	}
+
+	if rules.extra != nil {
+		problems = append(problems, rules.extra(attr)...)
+	}
+
+	return problems
+}
+
+// check returns what is wrong with value, or an empty string
+func (r FieldRule) check(country, value string) string {
+	switch {
+	case value == "" && r.Requirement == Required:
+		return fmt.Sprintf("is required for %s", country)
+	case value == "":
+		return ""
+	case r.Requirement == Forbidden:
+		return fmt.Sprintf("is not supported for %s", country)
+	case r.pattern != nil && !r.pattern.MatchString(value):
+		return fmt.Sprintf("expected %s, got %q", r.Format, value)
+	}
+
+	if r.validate != nil {
+		if err := r.validate(value); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// checkITBankID checks that Italian bank IDs only carry the national check
+// character when no account number is given
+func checkITBankID(attr *Attributes) []FieldError {
+	switch {
+	case len(attr.BankID) == 11 && attr.AccountNumber != "":
+		return []FieldError{{Field: "attributes.bank_id", Message: "must be 10 characters long when an account number is given"}}
+	case len(attr.BankID) == 10 && attr.AccountNumber == "":
+		return []FieldError{{Field: "attributes.bank_id", Message: "must be 11 characters long when no account number is given"}}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,144 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rulesCase struct {
+	attr   Attributes
+	fields []string
+}
+
+func TestCountryRules(t *testing.T) {
+	tests := map[string]map[string]rulesCase{
+		"GB": {
+			"valid":                {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBKGB22"}},
+			"valid with numbers":   {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBKGB22XXX", AccountNumber: "41426819", IBAN: "GB16NWBK40030041426819"}},
+			"missing bank details": {fields: []string{"attributes.bank_id", "attributes.bank_id_code", "attributes.bic"}},
+			"short sort code":      {attr: Attributes{BankID: "40030", BankIDCode: "GBDSC", BIC: "NWBKGB22"}, fields: []string{"attributes.bank_id"}},
+			"wrong bank id code":   {attr: Attributes{BankID: "400300", BankIDCode: "DEBLZ", BIC: "NWBKGB22"}, fields: []string{"attributes.bank_id_code"}},
+			"malformed bic":        {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBK22"}, fields: []string{"attributes.bic"}},
+			"long account number":  {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBKGB22", AccountNumber: "414268190"}, fields: []string{"attributes.account_number"}},
+			"wrong check digits":   {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBKGB22", IBAN: "GB11NWBK40030041426819"}, fields: []string{"attributes.iban"}},
+			"foreign iban":         {attr: Attributes{BankID: "400300", BankIDCode: "GBDSC", BIC: "NWBKGB22", IBAN: "DE89370400440532013000"}, fields: []string{"attributes.iban"}},
+		},
+		"AU": {
+			"valid":                 {attr: Attributes{BankID: "123456", BankIDCode: "AUBSB", BIC: "NATAAU33", AccountNumber: "1234567"}},
+			"valid without bank id": {attr: Attributes{BankIDCode: "AUBSB", BIC: "NATAAU33"}},
+			"missing bic":           {attr: Attributes{BankIDCode: "AUBSB"}, fields: []string{"attributes.bic"}},
+			"account number with 0": {attr: Attributes{BankIDCode: "AUBSB", BIC: "NATAAU33", AccountNumber: "0123456"}, fields: []string{"attributes.account_number"}},
+			"iban":                  {attr: Attributes{BankIDCode: "AUBSB", BIC: "NATAAU33", IBAN: "AU00123456"}, fields: []string{"attributes.iban"}},
+		},
+		"BE": {
+			"valid":           {attr: Attributes{BankID: "539", BankIDCode: "BE", AccountNumber: "0075470", IBAN: "BE68539007547034"}},
+			"missing bank id": {attr: Attributes{BankIDCode: "BE"}, fields: []string{"attributes.bank_id"}},
+			"long bank id":    {attr: Attributes{BankID: "5390", BankIDCode: "BE"}, fields: []string{"attributes.bank_id"}},
+		},
+		"CA": {
+			"valid":          {attr: Attributes{BankID: "012345678", BankIDCode: "CACPA", BIC: "ROYCCAT2", AccountNumber: "1234567"}},
+			"valid bic only": {attr: Attributes{BIC: "ROYCCAT2"}},
+			"routing number": {attr: Attributes{BankID: "123456789", BIC: "ROYCCAT2"}, fields: []string{"attributes.bank_id"}},
+			"short account":  {attr: Attributes{BIC: "ROYCCAT2", AccountNumber: "123456"}, fields: []string{"attributes.account_number"}},
+			"iban":           {attr: Attributes{BIC: "ROYCCAT2", IBAN: "CA00123"}, fields: []string{"attributes.iban"}},
+		},
+		"FR": {
+			"valid":         {attr: Attributes{BankID: "2004101005", BankIDCode: "FR", AccountNumber: "0500013M026", IBAN: "FR1420041010050500013M02606"}},
+			"wrong code":    {attr: Attributes{BankID: "2004101005", BankIDCode: "FRX"}, fields: []string{"attributes.bank_id_code"}},
+			"short bank id": {attr: Attributes{BankID: "20041", BankIDCode: "FR"}, fields: []string{"attributes.bank_id"}},
+		},
+		"DE": {
+			"valid":          {attr: Attributes{BankID: "37040044", BankIDCode: "DEBLZ", BIC: "COBADEFF", IBAN: "DE89370400440532013000"}},
+			"missing blz":    {attr: Attributes{BankIDCode: "DEBLZ"}, fields: []string{"attributes.bank_id"}},
+			"letters in blz": {attr: Attributes{BankID: "3704004A", BankIDCode: "DEBLZ"}, fields: []string{"attributes.bank_id"}},
+		},
+		"GR": {
+			"valid":     {attr: Attributes{BankID: "0110125", BankIDCode: "GRBIC", AccountNumber: "0000000012300695", IBAN: "GR1601101250000000012300695"}},
+			"long iban": {attr: Attributes{BankID: "0110125", BankIDCode: "GRBIC", IBAN: "GR16011012500000000123006950"}, fields: []string{"attributes.iban"}},
+		},
+		"HK": {
+			"valid":       {attr: Attributes{BankID: "004", BankIDCode: "HKNCC", BIC: "HSBCHKHH", AccountNumber: "123456789"}},
+			"missing bic": {attr: Attributes{BankID: "004"}, fields: []string{"attributes.bic"}},
+			"iban":        {attr: Attributes{BIC: "HSBCHKHH", IBAN: "HK00123"}, fields: []string{"attributes.iban"}},
+		},
+		"IT": {
+			"valid with account number":    {attr: Attributes{BankID: "0542811101", BankIDCode: "ITNCC", AccountNumber: "000000123456"}},
+			"valid without account number": {attr: Attributes{BankID: "X0542811101", BankIDCode: "ITNCC"}},
+			"check character with number":  {attr: Attributes{BankID: "X0542811101", BankIDCode: "ITNCC", AccountNumber: "000000123456"}, fields: []string{"attributes.bank_id"}},
+			"no check character":           {attr: Attributes{BankID: "0542811101", BankIDCode: "ITNCC"}, fields: []string{"attributes.bank_id"}},
+		},
+		"LU": {
+			"valid":      {attr: Attributes{BankID: "001", BankIDCode: "LULUX", AccountNumber: "9400644750000", IBAN: "LU280019400644750000"}},
+			"wrong code": {attr: Attributes{BankID: "001", BankIDCode: "LU"}, fields: []string{"attributes.bank_id_code"}},
+		},
+		"NL": {
+			"valid":       {attr: Attributes{BIC: "ABNANL2A", AccountNumber: "0417164300", IBAN: "NL91ABNA0417164300"}},
+			"bank id":     {attr: Attributes{BIC: "ABNANL2A", BankID: "ABNA", BankIDCode: "NL"}, fields: []string{"attributes.bank_id", "attributes.bank_id_code"}},
+			"missing bic": {attr: Attributes{}, fields: []string{"attributes.bic"}},
+		},
+		"PL": {
+			"valid":        {attr: Attributes{BankID: "10901014", BankIDCode: "PLKNR", AccountNumber: "0000071219812874", IBAN: "PL61109010140000071219812874"}},
+			"missing code": {attr: Attributes{BankID: "10901014"}, fields: []string{"attributes.bank_id_code"}},
+		},
+		"PT": {
+			"valid":         {attr: Attributes{BankID: "00020123", BankIDCode: "PTNCC", AccountNumber: "12345678901", IBAN: "PT50000201231234567890154"}},
+			"short account": {attr: Attributes{BankID: "00020123", BankIDCode: "PTNCC", AccountNumber: "1234567890"}, fields: []string{"attributes.account_number"}},
+		},
+		"ES": {
+			"valid":      {attr: Attributes{BankID: "21000418", BankIDCode: "ESNCC", AccountNumber: "0200051332", IBAN: "ES9121000418450200051332"}},
+			"wrong iban": {attr: Attributes{BankID: "21000418", BankIDCode: "ESNCC", IBAN: "ES91210004184502000513"}, fields: []string{"attributes.iban"}},
+		},
+		"CH": {
+			"valid":       {attr: Attributes{BankID: "00762", BankIDCode: "CHBCC", AccountNumber: "011623852957", IBAN: "CH9300762011623852957"}},
+			"missing all": {fields: []string{"attributes.bank_id", "attributes.bank_id_code"}},
+		},
+		"US": {
+			"valid":         {attr: Attributes{BankID: "021000021", BankIDCode: "USABA", BIC: "CHASUS33", AccountNumber: "123456789"}},
+			"short routing": {attr: Attributes{BankID: "02100002", BankIDCode: "USABA", BIC: "CHASUS33"}, fields: []string{"attributes.bank_id"}},
+			"iban":          {attr: Attributes{BankID: "021000021", BankIDCode: "USABA", BIC: "CHASUS33", IBAN: "US00123"}, fields: []string{"attributes.iban"}},
+		},
+	}
+
+	var countries []string
+	for country := range tests {
+		countries = append(countries, country)
+	}
+	assert.ElementsMatch(t, SupportedCountries(), countries, "every supported country is tested")
+
+	for country, cases := range tests {
+		for name, tc := range cases {
+			country, tc := country, tc
+			t.Run(country+"/"+name, func(t *testing.T) {
+				rules, ok := RulesFor(country)
+				assert.True(t, ok)
+
+				tc.attr.Country = country
+				var fields []string
+				for _, p := range rules.check(country, &tc.attr) {
+					fields = append(fields, p.Field)
+				}
+
+				assert.Equal(t, tc.fields, fields)
+			})
+		}
+	}
+}
+
+func TestUnsupportedCountryRules(t *testing.T) {
+	_, ok := RulesFor("JP")
+	assert.False(t, ok)
+
+	acc := validAccountCreate()
+	acc.Attributes = &Attributes{Country: "JP", Name: []string{"John Doe"}, BankID: "anything"}
+	assert.NoError(t, acc.Validate(), "countries without rules are not checked")
+}
+
+func TestRulesInValidate(t *testing.T) {
+	acc := validAccountCreate()
+	acc.Attributes.BankID = "4003"
+
+	err := acc.Validate()
+
+	assert.EqualError(t, err, `invalid account: attributes.bank_id: expected 6 digit sort code, got "4003"`)
+}
@@ -0,0 +1,115 @@
+package accounts
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	accountsType = "accounts"
+	maxNames     = 4
+)
+
+// FieldError describes a problem with a single field. Field is the JSON name
+// of the field, e.g. "attributes.country"
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when an account is rejected before being sent.
+// Problems lists every problem found, in field order
+type ValidationError struct {
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, p.Error())
+	}
+	return fmt.Sprintf("invalid account: %s", strings.Join(problems, "; "))
+}
+
+// Fields returns the names of the invalid fields
+func (e *ValidationError) Fields() []string {
+	fields := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		fields = append(fields, p.Field)
+	}
+	return fields
+}
+
+// Validate checks the account for the mistakes the API would reject it for:
+// * Type must be "accounts"
+// * ID and OrganisationID are required
+// * Country is required and must be an ISO 3166-1 alpha-2 code
+// * BaseCurrency, when set, must be an ISO 4217 code
+// * Name must hold 1 to 4 non blank entries
+// * AccountClassification, when set, must be Personal or Business
+// * the bank details must follow the rules of the country, see RulesFor
+// A *ValidationError listing every problem is returned when the account is invalid
+func (acc *AccountCreate) Validate() error {
+	var problems []FieldError
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if acc.Type != accountsType {
+		add("type", "must be %q, got %q", accountsType, acc.Type)
+	}
+	if acc.ID == nil {
+		add("id", "is required")
+	}
+	if acc.OrganisationID == nil {
+		add("organisation_id", "is required")
+	}
+
+	if acc.Attributes == nil {
+		add("attributes", "is required")
+		return &ValidationError{Problems: problems}
+	}
+	attr := acc.Attributes
+
+	switch {
+	case attr.Country == "":
+		add("attributes.country", "is required")
+	case !isCountryCode(attr.Country):
+		add("attributes.country", "must be an ISO 3166-1 alpha-2 code, got %q", attr.Country)
+	}
+
+	if rules, ok := RulesFor(attr.Country); ok {
+		problems = append(problems, rules.check(attr.Country, attr)...)
+	}
+
+	if attr.BaseCurrency != "" && !isCurrencyCode(attr.BaseCurrency) {
+		add("attributes.base_currency", "must be an ISO 4217 code, got %q", attr.BaseCurrency)
+	}
+
+	switch {
+	case len(attr.Name) == 0:
+		add("attributes.name", "is required")
+	case len(attr.Name) > maxNames:
+		add("attributes.name", "must have at most %d entries, got %d", maxNames, len(attr.Name))
+	default:
+		for i, n := range attr.Name {
+			if strings.TrimSpace(n) == "" {
+				add(fmt.Sprintf("attributes.name[%d]", i), "must not be blank")
+			}
+		}
+	}
+
+	if attr.AccountClassification != "" && !attr.AccountClassification.Known() {
+		add("attributes.account_classification", "must be %q or %q, got %q",
+			ClassificationPersonal, ClassificationBusiness, attr.AccountClassification)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAccountCreate(t *testing.T) {
+	tests := map[string]struct {
+		modify func(acc *AccountCreate)
+		fields []string
+	}{
+		"valid": {
+			modify: func(acc *AccountCreate) {},
+		},
+		"valid with every checked field": {
+			modify: func(acc *AccountCreate) {
+				acc.Attributes.BaseCurrency = "GBP"
+				acc.Attributes.Name = []string{"John", "Jack", "Doe", "Ltd"}
+				acc.Attributes.AccountClassification = "Business"
+			},
+		},
+		"wrong type": {
+			modify: func(acc *AccountCreate) { acc.Type = "account" },
+			fields: []string{"type"},
+		},
+		"missing ids": {
+			modify: func(acc *AccountCreate) {
+				acc.ID = nil
+				acc.OrganisationID = nil
+			},
+			fields: []string{"id", "organisation_id"},
+		},
+		"missing attributes": {
+			modify: func(acc *AccountCreate) { acc.Attributes = nil },
+			fields: []string{"attributes"},
+		},
+		"missing country": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Country = "" },
+			fields: []string{"attributes.country"},
+		},
+		"unknown country": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Country = "UK" },
+			fields: []string{"attributes.country"},
+		},
+		"lower case country": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Country = "gb" },
+			fields: []string{"attributes.country"},
+		},
+		"alpha-3 country": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Country = "GBR" },
+			fields: []string{"attributes.country"},
+		},
+		"unknown currency": {
+			modify: func(acc *AccountCreate) { acc.Attributes.BaseCurrency = "XYZ" },
+			fields: []string{"attributes.base_currency"},
+		},
+		"missing name": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Name = nil },
+			fields: []string{"attributes.name"},
+		},
+		"too many names": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Name = []string{"a", "b", "c", "d", "e"} },
+			fields: []string{"attributes.name"},
+		},
+		"blank name": {
+			modify: func(acc *AccountCreate) { acc.Attributes.Name = []string{"John Doe", " "} },
+			fields: []string{"attributes.name[1]"},
+		},
+		"unknown classification": {
+			modify: func(acc *AccountCreate) { acc.Attributes.AccountClassification = "personal" },
+			fields: []string{"attributes.account_classification"},
+		},
+		"every problem is listed": {
+			modify: func(acc *AccountCreate) {
+				acc.Type = ""
+				acc.ID = nil
+				acc.Attributes = &Attributes{Country: "ZZ", BaseCurrency: "EURO", AccountClassification: "Corporate"}
+			},
+			fields: []string{
+				"type", "id", "attributes.country", "attributes.base_currency",
+				"attributes.name", "attributes.account_classification",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			acc := validAccountCreate()
+			tc.modify(acc)
+
+			err := acc.Validate()
+
+			if tc.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tc.fields, validationErr.Fields())
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	acc := validAccountCreate()
+	acc.OrganisationID = nil
+	acc.Attributes.BaseCurrency = "gbp"
+
+	err := acc.Validate()
+
+	assert.EqualError(t, err, `invalid account: organisation_id: is required; `+
+		`attributes.base_currency: must be an ISO 4217 code, got "gbp"`)
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// randMu serialises calls to injected RandSources. A policy, and so its
+// backoff, is shared by every concurrent request of a resource
+var randMu sync.Mutex
+
+// RandSource is the source of randomness used by the jittered strategies.
+// *rand.Rand satisfies it, which lets tests inject a seeded or fixed source.
+// The strategies lock around it, so sources that are not safe for concurrent
+// use, *rand.Rand included, can be shared by concurrent requests.
+// A nil RandSource uses the math/rand package level source
+type RandSource interface {
+	// Int63n returns a non-negative random number in [0, n)
+	Int63n(n int64) int64
+}
+
+// Backoff computes the delay before a retry from the delays of the RetryPolicy
+type Backoff interface {
+	// Backoff returns the delay before the given retry, 1 being the first.
+	// prev is the delay returned for the previous retry, zero before the first
+	Backoff(retry int, base, max, prev time.Duration) time.Duration
+}
+
+// ConstantBackoff waits the base delay before every retry
+type ConstantBackoff struct{}
+
+func (ConstantBackoff) Backoff(retry int, base, max, prev time.Duration) time.Duration {
+	return capDelay(base, max)
+}
+
+// ExponentialBackoff doubles the delay with every retry, starting at the
+// base delay, until it reaches the max delay
+type ExponentialBackoff struct{}
+
+func (ExponentialBackoff) Backoff(retry int, base, max, prev time.Duration) time.Duration {
+	return exponential(retry, base, max)
+}
+
+// FullJitterBackoff waits a random delay between zero and the exponential
+// backoff delay. It spreads retries of many clients the most evenly
+type FullJitterBackoff struct {
+	Rand RandSource
+}
+
+func (b FullJitterBackoff) Backoff(retry int, base, max, prev time.Duration) time.Duration {
+	return between(b.Rand, 0, exponential(retry, base, max))
+}
+
+// DecorrelatedJitterBackoff waits a random delay between the base delay and
+// three times the previous delay, capped at the max delay
+type DecorrelatedJitterBackoff struct {
+	Rand RandSource
+}
+
+func (b DecorrelatedJitterBackoff) Backoff(retry int, base, max, prev time.Duration) time.Duration {
+	if prev < base {
+		prev = base
+	}
+
+	upper := prev * 3
+	if upper/3 != prev {
+		// Overflowed, nothing can be larger than the cap anyway
+		upper = math.MaxInt64
+	}
+
+	return capDelay(between(b.Rand, base, upper), max)
+}
+
+// exponential returns base * 2^(retry-1) without overflowing, capped at max
+func exponential(retry int, base, max time.Duration) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	d := base
+	for i := 1; i < retry && d > 0; i++ {
+		if d > math.MaxInt64/2 {
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+		if max > 0 && d >= max {
+			break
+		}
+	}
+
+	return capDelay(d, max)
+}
+
+// between returns a random duration in [lo, hi]
+func between(r RandSource, lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+
+	n := int64(hi - lo)
+	if n < math.MaxInt64 {
+		n++
+	}
+
+	return lo + time.Duration(randInt63n(r, n))
+}
+
+func randInt63n(r RandSource, n int64) int64 {
+	if r == nil {
+		return rand.Int63n(n) // nolint: gosec
+	}
+
+	randMu.Lock()
+	defer randMu.Unlock()
+	return r.Int63n(n)
+}
+
+func capDelay(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
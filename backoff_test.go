@@ -0,0 +1,135 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// halfRand always picks the middle of the requested range
+type halfRand struct{}
+
+func (halfRand) Int63n(n int64) int64 { return n / 2 }
+
+// maxRand always picks the top of the requested range
+type maxRand struct{}
+
+func (maxRand) Int63n(n int64) int64 { return n - 1 }
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{}
+
+	assert.Equal(t, time.Second, b.Backoff(1, time.Second, 0, 0))
+	assert.Equal(t, time.Second, b.Backoff(10, time.Second, 0, 5*time.Second))
+	assert.Equal(t, 500*time.Millisecond, b.Backoff(1, time.Second, 500*time.Millisecond, 0))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{}
+
+	var got []time.Duration
+	for retry := 1; retry <= 6; retry++ {
+		got = append(got, b.Backoff(retry, time.Second, 10*time.Second, 0))
+	}
+
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, got)
+
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Backoff(200, time.Second, 0, 0), "uncapped must not overflow")
+	assert.Equal(t, time.Duration(0), b.Backoff(3, 0, 0, 0))
+}
+
+func TestFullJitterBackoff(t *testing.T) {
+	b := FullJitterBackoff{Rand: halfRand{}}
+
+	var got []time.Duration
+	for retry := 1; retry <= 5; retry++ {
+		got = append(got, b.Backoff(retry, time.Second, 6*time.Second, 0))
+	}
+
+	// Half of [0, min(6s, 1s * 2^(retry-1))], both ends inclusive
+	assert.Equal(t, []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second,
+	}, got)
+
+	b.Rand = maxRand{}
+	assert.Equal(t, 4*time.Second, b.Backoff(3, time.Second, 6*time.Second, 0))
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := DecorrelatedJitterBackoff{Rand: halfRand{}}
+
+	var got []time.Duration
+	var prev time.Duration
+	for retry := 1; retry <= 5; retry++ {
+		prev = b.Backoff(retry, time.Second, 10*time.Second, prev)
+		got = append(got, prev)
+	}
+
+	// Middle of [1s, 3 * prev], prev starting at the base delay, capped at 10s
+	assert.Equal(t, []time.Duration{
+		2 * time.Second, 3500 * time.Millisecond, 5750 * time.Millisecond, 9125 * time.Millisecond, 10 * time.Second,
+	}, got)
+
+	b.Rand = maxRand{}
+	assert.Equal(t, 3*time.Second, b.Backoff(1, time.Second, 0, 0))
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Backoff(2, time.Second, 0, math.MaxInt64/2))
+}
+
+func TestJitteredBackoffStaysInBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(42)) // nolint: gosec
+
+	full := FullJitterBackoff{Rand: r}
+	decorrelated := DecorrelatedJitterBackoff{Rand: r}
+
+	var prev time.Duration
+	for retry := 1; retry <= 1000; retry++ {
+		d := full.Backoff(retry, 100*time.Millisecond, 5*time.Second, 0)
+		assert.True(t, d >= 0 && d <= 5*time.Second, d)
+
+		prev = decorrelated.Backoff(retry, 100*time.Millisecond, 5*time.Second, prev)
+		assert.True(t, prev >= 100*time.Millisecond && prev <= 5*time.Second, prev)
+	}
+}
+
+func TestJitteredBackoffSharedSource(t *testing.T) {
+	// Run with -race: a *rand.Rand is not safe for concurrent use on its own
+	src := rand.New(rand.NewSource(1)) // nolint: gosec
+	strategies := []Backoff{FullJitterBackoff{Rand: src}, DecorrelatedJitterBackoff{Rand: src}}
+	sleeper := &MockRetrySleeper{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for retry := 1; retry <= 50; retry++ {
+				for _, b := range strategies {
+					sleeper.Sleep(b.Backoff(retry, time.Millisecond, time.Second, 0))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, sleeper.Slept, 8*50*2)
+}
+
+func TestRetryPolicyDelayWithBackoff(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  5 * time.Second,
+		Backoff:   ExponentialBackoff{},
+		Jitter:    AdditiveJitter{Max: time.Second, Rand: halfRand{}},
+	}
+
+	assert.Equal(t, 1500*time.Millisecond, p.Delay(1, 0))
+	assert.Equal(t, 2500*time.Millisecond, p.Delay(2, 0))
+	assert.Equal(t, 4500*time.Millisecond, p.Delay(3, 0))
+	assert.Equal(t, 5*time.Second, p.Delay(4, 0), "jitter is capped too")
+}
@@ -0,0 +1,230 @@
+// Package cassette records the HTTP exchanges of a client.HTTPClient to JSON
+// lines and replays them, so that a session captured once against a real
+// server can be run again without one, e.g. in CI
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/banjoh/fake-api-client/iban"
+)
+
+// redacted replaces the values of redacted headers
+const redacted = "REDACTED"
+
+// maxLineSize bounds the size of a single recorded interaction
+const maxLineSize = 16 << 20
+
+// Interaction is a request and the response it got, one line of a cassette
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response. Error is set when the request failed
+// without a response, e.g. on a network error, or when reading the response
+// body failed, in which case Body holds what was read
+type Response struct {
+	StatusCode int         `json:"status_code,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Error      *Error      `json:"error,omitempty"`
+}
+
+// Kinds of recorded errors, telling apart the ways connections are dropped
+const (
+	KindConnectionReset = "connection_reset"
+	KindEOF             = "eof"
+	KindUnexpectedEOF   = "unexpected_eof"
+)
+
+// Error is a recorded request failure. It implements net.Error, and unwraps
+// to the error its Kind stands for, so that replayed network errors are
+// classified as the original ones were
+type Error struct {
+	Message   string `json:"message"`
+	Kind      string `json:"kind,omitempty"`
+	IsTimeout bool   `json:"timeout,omitempty"`
+	IsTemp    bool   `json:"temporary,omitempty"`
+}
+
+func (e *Error) Error() string   { return e.Message }
+func (e *Error) Timeout() bool   { return e.IsTimeout }
+func (e *Error) Temporary() bool { return e.IsTemp }
+
+// Unwrap returns syscall.ECONNRESET, io.EOF or io.ErrUnexpectedEOF according
+// to the kind of the error, nil for other errors
+func (e *Error) Unwrap() error {
+	switch e.Kind {
+	case KindConnectionReset:
+		return syscall.ECONNRESET
+	case KindEOF:
+		return io.EOF
+	case KindUnexpectedEOF:
+		return io.ErrUnexpectedEOF
+	default:
+		return nil
+	}
+}
+
+// Redaction describes what is masked out of the interactions before they
+// are recorded. Replayed requests are redacted the same way before being
+// matched, so that they still match their recording
+type Redaction struct {
+	// Headers are the names of the headers whose values are replaced,
+	// in requests and responses alike
+	Headers []string
+
+	// IBANs masks every valid IBAN found in URLs and bodies, but for its
+	// country code
+	IBANs bool
+}
+
+// DefaultRedaction returns the redaction recorders and replayers use unless
+// configured otherwise: the Authorization and Signature headers, and IBANs
+func DefaultRedaction() Redaction {
+	return Redaction{
+		Headers: []string{"Authorization", "Signature"},
+		IBANs:   true,
+	}
+}
+
+// ibanPattern finds IBAN candidates, which are then checked for validity
+var ibanPattern = regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}\b`)
+
+func (r Redaction) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	h = h.Clone()
+	for _, name := range r.Headers {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
+
+func (r Redaction) text(s string) string {
+	if !r.IBANs {
+		return s
+	}
+
+	return ibanPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if iban.Validate(m) != nil {
+			return m
+		}
+		return m[:2] + strings.Repeat("X", len(m)-2)
+	})
+}
+
+func (r Redaction) request(req Request) Request {
+	req.URL = r.text(req.URL)
+	req.Header = r.header(req.Header)
+	req.Body = r.text(req.Body)
+	return req
+}
+
+func (r Redaction) response(resp Response) Response {
+	resp.Header = r.header(resp.Header)
+	resp.Body = r.text(resp.Body)
+	return resp
+}
+
+// Load reads the interactions of a cassette, one JSON document per line.
+// Blank lines are skipped
+func Load(r io.Reader) ([]Interaction, error) {
+	var interactions []Interaction
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		var i Interaction
+		if err := json.Unmarshal(b, &i); err != nil {
+			return nil, fmt.Errorf("line %d: unmarshaling err: %w", line, err)
+		}
+		interactions = append(interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	return interactions, nil
+}
+
+// readRequest captures the request, leaving its body ready to be sent
+func readRequest(req *http.Request) (Request, error) {
+	rec := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return rec, nil
+	}
+
+	var body io.ReadCloser
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return rec, err
+		}
+	} else {
+		body = req.Body
+	}
+
+	b, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return rec, err
+	}
+
+	if req.GetBody == nil {
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
+	}
+
+	rec.Body = string(b)
+	return rec, nil
+}
+
+// body returns a response body yielding b, then err when not nil
+func body(b []byte, err error) io.ReadCloser {
+	if err == nil {
+		return io.NopCloser(bytes.NewReader(b))
+	}
+	return io.NopCloser(io.MultiReader(bytes.NewReader(b), errReader{err}))
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
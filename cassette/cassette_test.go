@@ -0,0 +1,396 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/banjoh/fake-api-client/fakeapi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIBAN = "GB16NWBK40030041426819"
+
+func newAccountsClient(t *testing.T, c client.HTTPClient, baseURL string) *accounts.Resource {
+	accClient, err := accounts.New(
+		accounts.WithBaseURL(baseURL),
+		accounts.WithHTTPClient(c),
+		accounts.WithRetrySleeper(&client.MockRetrySleeper{}),
+	)
+	require.NoError(t, err)
+	return accClient
+}
+
+// session runs the same calls against whichever client it is given
+func session(t *testing.T, accClient *accounts.Resource) (*accounts.Account, *accounts.AccountPage) {
+	ctx := context.Background()
+	accCreate, err := accounts.NewGBAccount("400300", "41426819").
+		ID(uuid.MustParse("ad27e265-9605-4b4b-a0e5-3003ea9cc4dc")).
+		OrganisationID(uuid.MustParse("eb0bd6f5-c3f5-44b2-b677-acd23cdde73c")).
+		BIC("NWBKGB22").
+		IBAN(testIBAN).
+		Name("John Doe").
+		Build()
+	require.NoError(t, err)
+
+	_, err = accClient.Create(ctx, accCreate)
+	require.NoError(t, err)
+
+	fetched, err := accClient.Fetch(ctx, *accCreate.ID)
+	require.NoError(t, err)
+
+	page, err := accClient.List(ctx, &accounts.ListOptions{Filter: accounts.NewFilter().IBAN(testIBAN)})
+	require.NoError(t, err)
+
+	require.NoError(t, accClient.Delete(ctx, *accCreate.ID, *fetched.Version))
+
+	return fetched, page
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := fakeapi.NewServer()
+
+	var cassette bytes.Buffer
+	recorder, err := NewRecorder(srv.Client(), &cassette)
+	require.NoError(t, err)
+
+	recorded, recordedPage := session(t, newAccountsClient(t, recorder, srv.URL))
+	assert.Equal(t, testIBAN, recorded.Attributes.IBAN)
+	require.Len(t, recordedPage.Accounts, 1)
+	srv.Close()
+
+	assert.Equal(t, 4, strings.Count(cassette.String(), "\n"))
+	assert.NotContains(t, cassette.String(), testIBAN)
+
+	interactions, err := Load(&cassette)
+	require.NoError(t, err)
+	require.Len(t, interactions, 4)
+	assert.Equal(t, "POST", interactions[0].Request.Method)
+	assert.Equal(t, http.StatusCreated, interactions[0].Response.StatusCode)
+
+	// The server is gone, the replayer answers on its behalf
+	replayer := NewReplayer(interactions)
+	replayed, replayedPage := session(t, newAccountsClient(t, replayer, srv.URL))
+
+	assert.Equal(t, 0, replayer.Remaining())
+	assert.Equal(t, *recorded.ID, *replayed.ID)
+	assert.Equal(t, *recorded.Version, *replayed.Version)
+	assert.Equal(t, "GBXXXXXXXXXXXXXXXXXXXX", replayed.Attributes.IBAN)
+	require.Len(t, replayedPage.Accounts, 1)
+
+	_, err = newAccountsClient(t, replayer, srv.URL).Fetch(context.Background(), *recorded.ID)
+	var noMatch *NoMatchError
+	assert.True(t, errors.As(err, &noMatch))
+}
+
+func TestRecorderRedaction(t *testing.T) {
+	mock := &client.MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}, "Signature": {"server-signature"}},
+			Body:       io.NopCloser(strings.NewReader(`{"iban": "` + testIBAN + `", "ref": "GB00NOTANIBAN0000000"}`)),
+		}, nil
+	}}
+
+	tests := map[string]struct {
+		redaction Redaction
+		hidden    []string
+		shown     []string
+	}{
+		"default": {
+			redaction: DefaultRedaction(),
+			hidden:    []string{"Bearer secret", "keyId=", "server-signature", testIBAN},
+			shown:     []string{"GBXXXXXXXXXXXXXXXXXXXX", "GB00NOTANIBAN0000000", "trace-id"},
+		},
+		"custom headers": {
+			redaction: Redaction{Headers: []string{"X-Trace"}},
+			hidden:    []string{"trace-id"},
+			shown:     []string{"Bearer secret", "server-signature", testIBAN},
+		},
+		"none": {
+			shown: []string{"Bearer secret", "keyId=", "server-signature", testIBAN, "trace-id"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var cassette bytes.Buffer
+			recorder, err := NewRecorderWithRedaction(mock, &cassette, tc.redaction)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("POST", "https://api.example.com/v1/accounts?filter[iban]="+testIBAN,
+				strings.NewReader(`{"iban": "`+testIBAN+`"}`))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("Signature", `keyId="my-key",signature="abc"`)
+			req.Header.Set("X-Trace", "trace-id")
+
+			resp, err := recorder.Do(req)
+			require.NoError(t, err)
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Contains(t, string(b), testIBAN, "the caller gets the response unredacted")
+			assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"), "the request is left untouched")
+			for _, s := range tc.hidden {
+				assert.NotContains(t, cassette.String(), s)
+			}
+			for _, s := range tc.shown {
+				assert.Contains(t, cassette.String(), s)
+			}
+		})
+	}
+}
+
+func TestReplayerMatching(t *testing.T) {
+	recorded := Interaction{
+		Request: Request{
+			Method: "POST",
+			URL:    "https://api.example.com/v1/accounts?page[number]=1&page[size]=10",
+			Body:   `{"data": {"id": "1", "type": "accounts"}}`,
+		},
+		Response: Response{StatusCode: http.StatusCreated, Body: `{}`},
+	}
+
+	tests := map[string]struct {
+		method string
+		url    string
+		body   string
+		match  bool
+	}{
+		"identical": {
+			method: "POST", url: recorded.Request.URL, body: recorded.Request.Body, match: true,
+		},
+		"other host": {
+			method: "POST", url: "http://localhost:8080/v1/accounts?page[number]=1&page[size]=10",
+			body: recorded.Request.Body, match: true,
+		},
+		"query in another order": {
+			method: "POST", url: "https://api.example.com/v1/accounts?page[size]=10&page[number]=1",
+			body: recorded.Request.Body, match: true,
+		},
+		"json formatted differently": {
+			method: "POST", url: recorded.Request.URL, body: `{"data":{"type":"accounts","id":"1"}}`, match: true,
+		},
+		"other method": {
+			method: "PUT", url: recorded.Request.URL, body: recorded.Request.Body,
+		},
+		"other path": {
+			method: "POST", url: "https://api.example.com/v1/account?page[number]=1&page[size]=10",
+			body: recorded.Request.Body,
+		},
+		"other query": {
+			method: "POST", url: "https://api.example.com/v1/accounts?page[number]=2&page[size]=10",
+			body: recorded.Request.Body,
+		},
+		"other body": {
+			method: "POST", url: recorded.Request.URL, body: `{"data": {"id": "2", "type": "accounts"}}`,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			replayer := NewReplayer([]Interaction{recorded})
+
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			resp, err := replayer.Do(req)
+
+			if tc.match {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+				assert.Equal(t, 0, replayer.Remaining())
+			} else {
+				var noMatch *NoMatchError
+				assert.True(t, errors.As(err, &noMatch))
+				assert.Equal(t, 1, replayer.Remaining())
+			}
+		})
+	}
+}
+
+func TestReplayRetriedRequest(t *testing.T) {
+	calls := 0
+	mock := &client.MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: &net.OpError{Op: "read", Err: &timeout{}}}
+		case 2:
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+		default:
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"data": {"type": "accounts", "version": 3}}`)),
+			}, nil
+		}
+	}}
+
+	var cassette bytes.Buffer
+	recorder, err := NewRecorder(mock, &cassette)
+	require.NoError(t, err)
+
+	id := uuid.New()
+	acc, err := newAccountsClient(t, recorder, "https://api.example.com").Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *acc.Version)
+	assert.Equal(t, 3, calls)
+
+	interactions, err := Load(&cassette)
+	require.NoError(t, err)
+	require.Len(t, interactions, 3)
+	require.NotNil(t, interactions[0].Response.Error)
+	assert.True(t, interactions[0].Response.Error.Timeout())
+
+	// The replayed timeout is retried like the recorded one was
+	replayer := NewReplayer(interactions)
+	acc, err = newAccountsClient(t, replayer, "https://api.example.com").Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *acc.Version)
+	assert.Equal(t, 0, replayer.Remaining())
+}
+
+func TestReplayDroppedConnections(t *testing.T) {
+	for _, dropErr := range []error{syscall.ECONNRESET, io.EOF, io.ErrUnexpectedEOF} {
+		calls := 0
+		mock := &client.MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, &url.Error{Op: "Delete", URL: req.URL.String(), Err: dropErr}
+			}
+			return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}}
+
+		var cassette bytes.Buffer
+		recorder, err := NewRecorder(mock, &cassette)
+		require.NoError(t, err)
+
+		id := uuid.New()
+		require.NoError(t, newAccountsClient(t, recorder, "https://api.example.com").Delete(context.Background(), id, 0))
+		assert.Equal(t, 2, calls, dropErr)
+
+		interactions, err := Load(&cassette)
+		require.NoError(t, err)
+		require.Len(t, interactions, 2)
+		assert.ErrorIs(t, interactions[0].Response.Error, dropErr)
+
+		replayer := NewReplayer(interactions)
+		assert.NoError(t, newAccountsClient(t, replayer, "https://api.example.com").Delete(context.Background(), id, 0), dropErr)
+		assert.Equal(t, 0, replayer.Remaining(), dropErr)
+	}
+}
+
+func TestReplayResetFault(t *testing.T) {
+	srv := fakeapi.NewServer()
+	defer srv.Close()
+
+	accCreate, err := accounts.NewGBAccount("400300", "41426819").OrganisationID(uuid.New()).BIC("NWBKGB22").Name("John Doe").Build()
+	require.NoError(t, err)
+	id := *accCreate.ID
+	created, err := newAccountsClient(t, srv.Client(), srv.URL).Create(context.Background(), accCreate)
+	require.NoError(t, err)
+
+	srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{
+		{Route: fakeapi.RouteDelete, Nth: []int{1}, Fault: fakeapi.ResetFault()},
+	}})
+
+	var cassette bytes.Buffer
+	recorder, err := NewRecorder(srv.Client(), &cassette)
+	require.NoError(t, err)
+	require.NoError(t, newAccountsClient(t, recorder, srv.URL).Delete(context.Background(), id, *created.Version))
+	assert.Equal(t, 2, srv.Requests(fakeapi.RouteDelete))
+
+	interactions, err := Load(&cassette)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+	require.NotNil(t, interactions[0].Response.Error)
+	assert.NotEmpty(t, interactions[0].Response.Error.Kind)
+
+	srv.Close()
+	replayer := NewReplayer(interactions)
+	assert.NoError(t, newAccountsClient(t, replayer, srv.URL).Delete(context.Background(), id, *created.Version))
+	assert.Equal(t, 0, replayer.Remaining())
+}
+
+func TestRecordBodyReadError(t *testing.T) {
+	readErr := errors.New("connection reset by peer")
+	mock := &client.MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(io.MultiReader(strings.NewReader(`{"data": {`), errReader{readErr})),
+		}, nil
+	}}
+
+	var cassette bytes.Buffer
+	recorder, err := NewRecorder(mock, &cassette)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "https://api.example.com/v1/accounts", nil)
+	require.NoError(t, err)
+	resp, err := recorder.Do(req)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"data": {`, string(b))
+	assert.ErrorIs(t, err, readErr)
+
+	interactions, err := Load(&cassette)
+	require.NoError(t, err)
+
+	resp, err = NewReplayer(interactions).Do(req)
+	require.NoError(t, err)
+	b, err = io.ReadAll(resp.Body)
+	assert.Equal(t, `{"data": {`, string(b))
+	assert.EqualError(t, err, readErr.Error())
+}
+
+func TestReplayerCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.example.com/v1/accounts", nil)
+	require.NoError(t, err)
+
+	_, err = NewReplayer(nil).Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLoad(t *testing.T) {
+	interactions, err := Load(strings.NewReader(`{"request": {"method": "GET", "url": "/a"}, "response": {"status_code": 200}}
+
+{"request": {"method": "DELETE", "url": "/a"}, "response": {"status_code": 204}}
+`))
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+	assert.Equal(t, "DELETE", interactions[1].Request.Method)
+
+	_, err = Load(strings.NewReader(`{"request": {"method": "GET"}}` + "\n" + `{"request": `))
+	assert.EqualError(t, err, "line 2: unmarshaling err: unexpected end of JSON input")
+}
+
+func TestNewRecorderErrors(t *testing.T) {
+	_, err := NewRecorder(nil, &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = NewRecorder(&client.MockClient{}, nil)
+	assert.Error(t, err)
+}
+
+type timeout struct{}
+
+func (timeout) Error() string   { return "i/o timeout" }
+func (timeout) Timeout() bool   { return true }
+func (timeout) Temporary() bool { return true }
@@ -0,0 +1,113 @@
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+
+	client "github.com/banjoh/fake-api-client"
+)
+
+// Recorder is an HTTPClient decorator writing every request sent through it,
+// along with its response or error, to a cassette. Interactions are redacted
+// before being written. A single Recorder is safe for concurrent use
+type Recorder struct {
+	client    client.HTTPClient
+	redaction Redaction
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder wraps c so that the requests it sends are recorded to w,
+// redacted as DefaultRedaction describes
+func NewRecorder(c client.HTTPClient, w io.Writer) (*Recorder, error) {
+	return NewRecorderWithRedaction(c, w, DefaultRedaction())
+}
+
+// NewRecorderWithRedaction wraps c so that the requests it sends are
+// recorded to w, redacted as r describes
+func NewRecorderWithRedaction(c client.HTTPClient, w io.Writer, r Redaction) (*Recorder, error) {
+	if c == nil {
+		return nil, fmt.Errorf("cassette.NewRecorder: nil HTTPClient")
+	}
+	if w == nil {
+		return nil, fmt.Errorf("cassette.NewRecorder: nil Writer")
+	}
+	r.Headers = append([]string(nil), r.Headers...)
+
+	return &Recorder{client: c, redaction: r, enc: json.NewEncoder(w)}, nil
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	recReq, err := readRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	i := Interaction{Request: r.redaction.request(recReq)}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		i.Response.Error = recordError(err)
+		if recErr := r.write(i); recErr != nil {
+			return nil, recErr
+		}
+		return nil, err
+	}
+
+	b, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = body(b, nil)
+	if readErr != nil {
+		// The body is recorded as far as it was read, along with the error
+		// reading it broke off with. The caller gets to read the same
+		i.Response.Error = recordError(readErr)
+		resp.Body = body(b, readErr)
+	}
+
+	i.Response.StatusCode = resp.StatusCode
+	i.Response.Header = resp.Header
+	i.Response.Body = string(b)
+	i.Response = r.redaction.response(i.Response)
+
+	if err := r.write(i); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) write(i Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(i); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return nil
+}
+
+func recordError(err error) *Error {
+	e := &Error{Message: err.Error()}
+
+	switch {
+	case errors.Is(err, syscall.ECONNRESET):
+		e.Kind = KindConnectionReset
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		e.Kind = KindUnexpectedEOF
+	case errors.Is(err, io.EOF):
+		e.Kind = KindEOF
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		e.IsTimeout = ne.Timeout()
+		e.IsTemp = ne.Temporary() // nolint: staticcheck
+	}
+	return e
+}
@@ -0,0 +1,156 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// NoMatchError is returned by Replayer when no recorded interaction is left
+// matching the request
+type NoMatchError struct {
+	Method string
+	URL    string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("no recorded interaction matches request: method=%s, url=%s", e.Method, e.URL)
+}
+
+// Replayer is an HTTPClient answering requests from recorded interactions,
+// without sending them. A request matches an interaction with the same
+// method, path, query and body. JSON bodies are compared as documents and
+// queries regardless of the order of their parameters. Every interaction is
+// replayed once, in the order it was recorded, so that a retried request gets
+// the responses its attempts got. A single Replayer is safe for concurrent use
+type Replayer struct {
+	redaction Redaction
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a Replayer for the given interactions, recorded with
+// DefaultRedaction
+func NewReplayer(interactions []Interaction) *Replayer {
+	return NewReplayerWithRedaction(interactions, DefaultRedaction())
+}
+
+// NewReplayerWithRedaction creates a Replayer for the given interactions,
+// recorded with the redaction r
+func NewReplayerWithRedaction(interactions []Interaction, r Redaction) *Replayer {
+	return &Replayer{
+		redaction:    r,
+		interactions: append([]Interaction(nil), interactions...),
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	recReq, err := readRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	recReq = r.redaction.request(recReq)
+
+	i, ok := r.next(recReq)
+	if !ok {
+		return nil, &NoMatchError{Method: req.Method, URL: recReq.URL}
+	}
+
+	if i.Response.Error != nil && i.Response.StatusCode == 0 {
+		return nil, i.Response.Error
+	}
+
+	var readErr error
+	if i.Response.Error != nil {
+		readErr = i.Response.Error
+	}
+
+	header := i.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(i.Response.StatusCode) + " " + http.StatusText(i.Response.StatusCode),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body([]byte(i.Response.Body), readErr),
+		ContentLength: int64(len(i.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns how many interactions are yet to be replayed
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// next takes the first interaction left matching req
+func (r *Replayer) next(req Request) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if !r.used[i] && matches(interaction.Request, req) {
+			r.used[i] = true
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// matches compares requests on their method, path, query and body
+func matches(recorded, req Request) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+
+	ru, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return false
+	}
+	if ru.Path != u.Path || ru.Query().Encode() != u.Query().Encode() {
+		return false
+	}
+
+	return sameBody(recorded.Body, req.Body)
+}
+
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var da, db interface{}
+	if json.Unmarshal([]byte(a), &da) != nil || json.Unmarshal([]byte(b), &db) != nil {
+		return false
+	}
+	return reflect.DeepEqual(da, db)
+}
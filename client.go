@@ -1,8 +1,10 @@
 package client
 
 import (
+	"context"
 	"math/rand"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -50,10 +52,50 @@ type RetrySleeper interface {
 	Sleep(d time.Duration)
 }
 
+// ContextSleeper is a RetrySleeper whose sleeps can be cut short. Resources
+// use it when their RetrySleeper implements it, so that cancelling a request
+// also stops the wait before its next attempt
+type ContextSleeper interface {
+	// SleepContext sleeps for d or until ctx is done, returning ctx's error
+	// in the latter case
+	SleepContext(ctx context.Context, d time.Duration) error
+}
+
 type DefaultRetrySleeper struct{}
 
 func (s *DefaultRetrySleeper) Sleep(d time.Duration) { time.Sleep(d) }
 
-type MockRetrySleeper struct{}
+func (s *DefaultRetrySleeper) SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-func (s *MockRetrySleeper) Sleep(d time.Duration) {}
+// MockRetrySleeper does not sleep. It records the requested
+// durations instead so that tests can assert on them. It can be shared by
+// concurrent requests
+type MockRetrySleeper struct {
+	mu    sync.Mutex
+	Slept []time.Duration
+}
+
+func (s *MockRetrySleeper) Sleep(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Slept = append(s.Slept, d)
+}
+
+// SleepContext records d like Sleep, failing instead when ctx is done
+func (s *MockRetrySleeper) SleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.Sleep(d)
+	return nil
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/google/uuid"
+)
+
+// maxUpdateAttempts bounds the updates tried by update without --version
+const maxUpdateAttempts = 3
+
+// attributeFlags are the account attributes settable from flags
+type attributeFlags struct {
+	country          string
+	baseCurrency     string
+	bankID           string
+	bankIDCode       string
+	bic              string
+	accountNumber    string
+	iban             string
+	customerID       string
+	names            stringList
+	alternativeNames stringList
+	classification   string
+	secondaryID      string
+	status           string
+	jointAccount     boolFlag
+	matchingOptOut   boolFlag
+	switched         boolFlag
+}
+
+func (f *attributeFlags) register(fs *flag.FlagSet, create bool) {
+	if create {
+		fs.StringVar(&f.country, "country", "", "ISO 3166-1 country code of the account")
+		fs.StringVar(&f.baseCurrency, "currency", "", "ISO 4217 base currency, defaults to the country's")
+		fs.StringVar(&f.bankID, "bank-id", "", "bank ID, e.g. a sort code")
+		fs.StringVar(&f.bankIDCode, "bank-id-code", "", "bank ID code, defaults to the country's")
+		fs.StringVar(&f.bic, "bic", "", "SWIFT BIC")
+		fs.StringVar(&f.accountNumber, "account-number", "", "account number")
+		fs.StringVar(&f.iban, "iban", "", "IBAN")
+		fs.Var(&f.jointAccount, "joint-account", "whether the account is held jointly")
+	} else {
+		fs.StringVar(&f.status, "status", "", "status: pending, confirmed or failed")
+	}
+	fs.StringVar(&f.customerID, "customer-id", "", "customer ID")
+	fs.Var(&f.names, "name", "name of the account holder, repeatable up to 4 times")
+	fs.Var(&f.alternativeNames, "alternative-name", "alternative name of the account holder, repeatable")
+	fs.StringVar(&f.classification, "classification", "", "account classification: Personal or Business")
+	fs.StringVar(&f.secondaryID, "secondary-id", "", "secondary identification, e.g. a building society roll number")
+	fs.Var(&f.matchingOptOut, "matching-opt-out", "whether the account opted out of account matching")
+	fs.Var(&f.switched, "switched", "whether the account was switched to another bank")
+}
+
+// apply overrides attributes with the flags that were set
+func (f *attributeFlags) apply(attr *accounts.Attributes) {
+	for _, field := range []struct {
+		flag  string
+		value *string
+	}{
+		{f.country, &attr.Country},
+		{f.baseCurrency, &attr.BaseCurrency},
+		{f.bankID, &attr.BankID},
+		{f.bic, &attr.BIC},
+		{f.accountNumber, &attr.AccountNumber},
+		{f.iban, &attr.IBAN},
+		{f.customerID, &attr.CustomerID},
+		{f.secondaryID, &attr.SecondaryIdentification},
+	} {
+		if field.flag != "" {
+			*field.value = field.flag
+		}
+	}
+
+	if f.bankIDCode != "" {
+		attr.BankIDCode = accounts.BankIDCode(f.bankIDCode)
+	}
+	if f.classification != "" {
+		attr.AccountClassification = accounts.AccountClassification(f.classification)
+	}
+	if f.status != "" {
+		attr.Status = accounts.AccountStatus(f.status)
+	}
+	if len(f.names) > 0 {
+		attr.Name = f.names
+	}
+	if len(f.alternativeNames) > 0 {
+		attr.AlternativeNames = f.alternativeNames
+	}
+	if v := f.jointAccount.ptr(); v != nil {
+		attr.JointAccount = v
+	}
+	if v := f.matchingOptOut.ptr(); v != nil {
+		attr.AccountMatchingOptOut = v
+	}
+	if v := f.switched.ptr(); v != nil {
+		attr.Switched = v
+	}
+}
+
+// build sets the flags that were set on the builder
+func (f *attributeFlags) build(b *accounts.AccountBuilder) {
+	for _, field := range []struct {
+		flag string
+		set  func(string) *accounts.AccountBuilder
+	}{
+		{f.baseCurrency, b.BaseCurrency},
+		{f.bankID, b.BankID},
+		{f.bic, b.BIC},
+		{f.accountNumber, b.AccountNumber},
+		{f.iban, b.IBAN},
+		{f.customerID, b.CustomerID},
+		{f.secondaryID, b.SecondaryIdentification},
+	} {
+		if field.flag != "" {
+			field.set(field.flag)
+		}
+	}
+
+	if f.bankIDCode != "" {
+		b.BankIDCode(accounts.BankIDCode(f.bankIDCode))
+	}
+	if f.classification != "" {
+		b.Classification(accounts.AccountClassification(f.classification))
+	}
+	if len(f.names) > 0 {
+		b.Name(f.names...)
+	}
+	if len(f.alternativeNames) > 0 {
+		b.AlternativeNames(f.alternativeNames...)
+	}
+	if v := f.jointAccount.ptr(); v != nil {
+		b.JointAccount(*v)
+	}
+	if v := f.matchingOptOut.ptr(); v != nil {
+		b.AccountMatchingOptOut(*v)
+	}
+	if v := f.switched.ptr(); v != nil {
+		b.Switched(*v)
+	}
+}
+
+func runCreate(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("create", e)
+	var attrs attributeFlags
+	attrs.register(fs, true)
+	file := fs.String("file", "", "JSON or YAML file holding the account, - for stdin. Flags override its attributes")
+	id := fs.String("id", "", "ID of the account, generated when not given")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %v", positional)
+	}
+
+	cfg, err := g.load(e)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPrinter(cfg.Output, e.stdout)
+	if err != nil {
+		return err
+	}
+
+	acc, err := accountCreate(e, cfg, &attrs, *file, *id)
+	if err != nil {
+		return err
+	}
+
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	created, err := r.Create(ctx, acc)
+	if err != nil {
+		return err
+	}
+	return p.account(created)
+}
+
+// accountCreate builds the account to create from the input file, or from
+// the flags alone with the defaults of the account's country
+func accountCreate(e *env, cfg *config, attrs *attributeFlags, file, id string) (*accounts.AccountCreate, error) {
+	orgID, err := cfg.organisationID()
+	if err != nil {
+		return nil, err
+	}
+
+	var acc *accounts.AccountCreate
+	if file != "" {
+		acc = &accounts.AccountCreate{}
+		if err := readFile(file, e.stdin, acc); err != nil {
+			return nil, err
+		}
+		if acc.Attributes == nil {
+			acc.Attributes = &accounts.Attributes{}
+		}
+		attrs.apply(acc.Attributes)
+		if acc.Type == "" {
+			acc.Type = "accounts"
+		}
+		if acc.ID == nil {
+			generated := uuid.New()
+			acc.ID = &generated
+		}
+	} else {
+		if attrs.country == "" {
+			return nil, usagef("either -file or -country is required")
+		}
+
+		// The builder fills in the country's defaults, the flags override them
+		b := accounts.NewAccountBuilder(attrs.country)
+		if orgID != nil {
+			b.OrganisationID(*orgID)
+		}
+		attrs.build(b)
+		if acc, err = b.Build(); err != nil {
+			return nil, err
+		}
+	}
+
+	if id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, usagef("invalid ID %q", id)
+		}
+		acc.ID = &parsed
+	}
+	if acc.OrganisationID == nil {
+		acc.OrganisationID = orgID
+	}
+
+	return acc, nil
+}
+
+func runGet(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("get", e)
+
+	id, cfg, err := parseIDCommand(e, fs, g, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPrinter(cfg.Output, e.stdout)
+	if err != nil {
+		return err
+	}
+
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	acc, err := r.Fetch(ctx, id)
+	if err != nil {
+		return err
+	}
+	return p.account(acc)
+}
+
+func runDelete(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("delete", e)
+	version := fs.Int("version", -1, "version of the account, the current one when not given")
+
+	id, cfg, err := parseIDCommand(e, fs, g, args)
+	if err != nil {
+		return err
+	}
+
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	if *version < 0 {
+		acc, err := r.Fetch(ctx, id)
+		if err != nil {
+			return err
+		}
+		if acc.Version == nil {
+			return fmt.Errorf("account %s was fetched without a version, set -version", id)
+		}
+		*version = *acc.Version
+	}
+
+	return r.Delete(ctx, id, *version)
+}
+
+func runUpdate(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("update", e)
+	var attrs attributeFlags
+	attrs.register(fs, false)
+	file := fs.String("file", "", "JSON or YAML file holding the attributes to change, - for stdin. Flags override them")
+	version := fs.Int("version", -1, "version of the account, the current one when not given")
+
+	id, cfg, err := parseIDCommand(e, fs, g, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPrinter(cfg.Output, e.stdout)
+	if err != nil {
+		return err
+	}
+
+	patch := &accounts.AccountUpdate{}
+	if *file != "" {
+		if err := readFile(*file, e.stdin, patch); err != nil {
+			return err
+		}
+	}
+	if patch.Attributes == nil {
+		patch.Attributes = &accounts.Attributes{}
+	}
+	attrs.apply(patch.Attributes)
+	if reflect.DeepEqual(*patch.Attributes, accounts.Attributes{}) {
+		return usagef("nothing to update, set -file or attribute flags")
+	}
+
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	var updated *accounts.Account
+	if *version < 0 {
+		updated, err = r.UpdateWithRefetch(ctx, id, patch, maxUpdateAttempts)
+	} else {
+		updated, err = r.Update(ctx, id, *version, patch)
+	}
+	if err != nil {
+		return err
+	}
+	return p.account(updated)
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("list", e)
+	pageNumber := fs.Int("page-number", 0, "number of the page to list, the first being 0")
+	pageSize := fs.Int("page-size", 0, "number of accounts per page, the server's default when not given")
+	all := fs.Bool("all", false, "list the accounts of every page, starting from -page-number")
+	var filters filterFlags
+	filters.register(fs, "list")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %v", positional)
+	}
+	if *pageNumber < 0 || *pageSize < 0 {
+		return usagef("page number and size must not be negative")
+	}
+
+	cfg, err := g.load(e)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPrinter(cfg.Output, e.stdout)
+	if err != nil {
+		return err
+	}
+
+	opts := accounts.ListOptions{PageNumber: *pageNumber, PageSize: *pageSize, Filter: filters.filter()}
+
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	if *all {
+		it := r.Iterate(ctx, &accounts.IteratorOptions{ListOptions: opts, Prefetch: true})
+		for it.Next() {
+			p.add(it.Account())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		return p.flush()
+	}
+
+	page, err := r.List(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	for i := range page.Accounts {
+		p.add(&page.Accounts[i])
+	}
+	return p.flush()
+}
+
+// parseIDCommand parses the command line of commands taking an account ID
+func parseIDCommand(e *env, fs *flag.FlagSet, g *globalFlags, args []string) (uuid.UUID, *config, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return uuid.UUID{}, nil, err
+	}
+	if len(positional) != 1 {
+		return uuid.UUID{}, nil, usagef("expected a single account ID, got %d arguments", len(positional))
+	}
+
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return uuid.UUID{}, nil, usagef("invalid account ID %q", positional[0])
+	}
+
+	cfg, err := g.load(e)
+	if err != nil {
+		return uuid.UUID{}, nil, err
+	}
+	return id, cfg, nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// errHelp is returned when the help of a command was asked for
+var errHelp = flag.ErrHelp
+
+// config holds the settings shared by every command, read from the config
+// file, the environment and the flags in increasing precedence
+type config struct {
+	BaseURL        string `yaml:"base_url"`
+	OrganisationID string `yaml:"organisation_id"`
+	Output         string `yaml:"output"`
+	Timeout        string `yaml:"timeout"`
+	Auth           auth   `yaml:"auth"`
+}
+
+// auth configures OAuth2 client credentials and request signing, both
+// optional and usable together
+type auth struct {
+	TokenURL       string   `yaml:"token_url"`
+	ClientID       string   `yaml:"client_id"`
+	ClientSecret   string   `yaml:"client_secret"`
+	Scopes         []string `yaml:"scopes"`
+	SigningKeyID   string   `yaml:"signing_key_id"`
+	SigningKeyFile string   `yaml:"signing_key_file"`
+}
+
+// setting binds a config field to its flag and environment variable
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *config) *string
+}
+
+var settings = []setting{
+	{"base-url", "ACCOUNTS_BASE_URL", "base URL of the API", func(c *config) *string { return &c.BaseURL }},
+	{"org-id", "ACCOUNTS_ORGANISATION_ID", "organisation ID accounts are created in",
+		func(c *config) *string { return &c.OrganisationID }},
+	{"output", "ACCOUNTS_OUTPUT", "output format: table, json or yaml", func(c *config) *string { return &c.Output }},
+	{"timeout", "ACCOUNTS_TIMEOUT", "timeout of each HTTP request, e.g. 30s", func(c *config) *string { return &c.Timeout }},
+	{"token-url", "ACCOUNTS_TOKEN_URL", "OAuth2 token URL", func(c *config) *string { return &c.Auth.TokenURL }},
+	{"client-id", "ACCOUNTS_CLIENT_ID", "OAuth2 client ID", func(c *config) *string { return &c.Auth.ClientID }},
+	{"client-secret", "ACCOUNTS_CLIENT_SECRET", "OAuth2 client secret, preferably set through the environment",
+		func(c *config) *string { return &c.Auth.ClientSecret }},
+	{"signing-key-id", "ACCOUNTS_SIGNING_KEY_ID", "ID of the key requests are signed with",
+		func(c *config) *string { return &c.Auth.SigningKeyID }},
+	{"signing-key-file", "ACCOUNTS_SIGNING_KEY_FILE", "PEM file of the private key requests are signed with",
+		func(c *config) *string { return &c.Auth.SigningKeyFile }},
+}
+
+// globalFlags are the flags every command accepts
+type globalFlags struct {
+	fs         *flag.FlagSet
+	configFile string
+	scopes     stringList
+	values     config
+}
+
+// newFlagSet creates the flag set of a command, global flags included
+func newFlagSet(name string, e *env) (*flag.FlagSet, *globalFlags) {
+	fs := flag.NewFlagSet("accounts "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+
+	g := &globalFlags{fs: fs}
+	fs.StringVar(&g.configFile, "config", "", "config file, defaults to $ACCOUNTS_CONFIG or ~/.config/fake-api-client/accounts.yaml")
+	fs.Var(&g.scopes, "scope", "OAuth2 scope to request, repeatable (env ACCOUNTS_SCOPES, space separated)")
+	for _, s := range settings {
+		fs.StringVar(s.field(&g.values), s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	fs.StringVar(&g.values.Output, "o", "", "shorthand for -output")
+
+	return fs, g
+}
+
+// parseArgs parses the flags of a command, which may come before or after
+// its positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, errHelp
+			}
+			return nil, usagef("%v", err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// load merges the config file, the environment and the flags
+func (g *globalFlags) load(e *env) (*config, error) {
+	var cfg config
+
+	path, explicit := g.configFile, g.configFile != ""
+	if !explicit {
+		path, explicit = e.getenv("ACCOUNTS_CONFIG"), e.getenv("ACCOUNTS_CONFIG") != ""
+	}
+	if !explicit {
+		path = defaultConfigFile(e)
+	}
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(b, &cfg); err != nil {
+				return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		case explicit || !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	for _, s := range settings {
+		if v := e.getenv(s.env); v != "" {
+			*s.field(&cfg) = v
+		}
+	}
+	if v := e.getenv("ACCOUNTS_SCOPES"); v != "" {
+		cfg.Auth.Scopes = strings.Fields(v)
+	}
+
+	set := map[string]bool{}
+	g.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, s := range settings {
+		if set[s.flag] {
+			*s.field(&cfg) = *s.field(&g.values)
+		}
+	}
+	if set["o"] {
+		cfg.Output = g.values.Output
+	}
+	if set["scope"] {
+		cfg.Auth.Scopes = g.scopes
+	}
+
+	if cfg.Output == "" {
+		cfg.Output = formatTable
+	}
+
+	return &cfg, nil
+}
+
+func defaultConfigFile(e *env) string {
+	dir := e.getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home := e.getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "fake-api-client", "accounts.yaml")
+}
+
+// organisationID parses the configured organisation ID, nil when not set
+func (c *config) organisationID() (*uuid.UUID, error) {
+	if c.OrganisationID == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(c.OrganisationID)
+	if err != nil {
+		return nil, usagef("invalid organisation ID %q", c.OrganisationID)
+	}
+	return &id, nil
+}
+
+// resource creates the accounts resource the commands use, authenticating
+// requests as configured
+func (c *config) resource() (*accounts.Resource, error) {
+	httpClient := client.DefaultClient
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, usagef("invalid timeout %q", c.Timeout)
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		httpClient = &http.Client{Timeout: timeout, Transport: t}
+	}
+
+	// Tokens are requested with the plain client, only requests to the API
+	// are signed
+	if c.Auth.TokenURL != "" || c.Auth.ClientID != "" {
+		oauth, err := client.NewOAuth2Client(httpClient, client.OAuth2Config{
+			TokenURL:     c.Auth.TokenURL,
+			ClientID:     c.Auth.ClientID,
+			ClientSecret: c.Auth.ClientSecret,
+			Scopes:       c.Auth.Scopes,
+		})
+		if err != nil {
+			return nil, usagef("%v", err)
+		}
+		httpClient = oauth
+	}
+
+	if c.Auth.SigningKeyID != "" || c.Auth.SigningKeyFile != "" {
+		if c.Auth.SigningKeyID == "" || c.Auth.SigningKeyFile == "" {
+			return nil, usagef("request signing needs both a signing key ID and a signing key file")
+		}
+
+		pem, err := os.ReadFile(c.Auth.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		signer, err := client.NewSignerFromPEM(c.Auth.SigningKeyID, pem)
+		if err != nil {
+			return nil, err
+		}
+		if httpClient, err = client.NewSigningClient(httpClient, signer); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []accounts.Option{accounts.WithHTTPClient(httpClient), accounts.WithUserAgent("fake-api-client-cli")}
+	if c.BaseURL != "" {
+		opts = append(opts, accounts.WithBaseURL(c.BaseURL))
+	}
+
+	r, err := accounts.New(opts...)
+	if err != nil {
+		return nil, usagef("%v", err)
+	}
+	return r, nil
+}
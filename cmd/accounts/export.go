@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/banjoh/fake-api-client/accounts"
+)
+
+// exportColumns are the CSV columns exported by default, which import reads
+// back as they are
+var exportColumns = append(append([]string{fieldID, fieldOrganisationID, "version"}, attributeColumns...),
+	"created_on", "modified_on")
+
+// filterFlags are the account filters of list and export
+type filterFlags struct {
+	country, bankID, bankIDCode, accountNumber, iban, customerID stringList
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet, verb string) {
+	fs.Var(&f.country, "country", "only "+verb+" accounts of this country, repeatable")
+	fs.Var(&f.bankID, "bank-id", "only "+verb+" accounts with this bank ID, repeatable")
+	fs.Var(&f.bankIDCode, "bank-id-code", "only "+verb+" accounts with this bank ID code, repeatable")
+	fs.Var(&f.accountNumber, "account-number", "only "+verb+" accounts with this account number, repeatable")
+	fs.Var(&f.iban, "iban", "only "+verb+" accounts with this IBAN, repeatable")
+	fs.Var(&f.customerID, "customer-id", "only "+verb+" accounts of this customer, repeatable")
+}
+
+func (f *filterFlags) filter() *accounts.Filter {
+	filter := accounts.NewFilter().
+		Country(f.country...).
+		BankID(f.bankID...).
+		AccountNumber(f.accountNumber...).
+		IBAN(f.iban...).
+		CustomerID(f.customerID...)
+	for _, code := range f.bankIDCode {
+		filter.BankIDCode(accounts.BankIDCode(code))
+	}
+	return filter
+}
+
+func runExport(ctx context.Context, e *env, args []string) (err error) {
+	fs, g := newFlagSet("export", e)
+	file := fs.String("file", "-", "file to export to, - for stdout")
+	format := fs.String("format", "", "format of the export: csv or ndjson, guessed from the file extension, "+
+		"csv for stdout")
+	columns := fs.String("columns", strings.Join(exportColumns, ","), "comma separated CSV columns")
+	pageSize := fs.Int("page-size", 0, "number of accounts fetched per request, the server's default when not given")
+	var filters filterFlags
+	filters.register(fs, "export")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %v", positional)
+	}
+	if *pageSize < 0 {
+		return usagef("page size must not be negative")
+	}
+
+	if *format == "" {
+		*format = formatCSV
+		if *file != "-" {
+			if *format, err = guessFormat(*file); err != nil {
+				return err
+			}
+		}
+	}
+
+	var cols []string
+	switch *format {
+	case formatCSV:
+		if cols, err = parseColumns(*columns); err != nil {
+			return err
+		}
+	case formatNDJSON:
+	default:
+		return usagef("unknown export format %q, expected csv or ndjson", *format)
+	}
+
+	cfg, err := g.load(e)
+	if err != nil {
+		return err
+	}
+	r, err := cfg.resource()
+	if err != nil {
+		return err
+	}
+
+	out := e.stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		// Closing reports write errors the file system deferred
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to write export: %w", closeErr)
+			}
+		}()
+		out = f
+	}
+
+	write, flush := ndjsonWriter(out)
+	if *format == formatCSV {
+		write, flush = csvWriter(out, cols)
+	}
+
+	it := r.Iterate(ctx, &accounts.IteratorOptions{
+		ListOptions: accounts.ListOptions{PageSize: *pageSize, Filter: filters.filter()},
+		Prefetch:    true,
+	})
+	count := 0
+	for it.Next() {
+		if err := write(it.Account()); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		count++
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("export interrupted after %d accounts: %w", count, err)
+	}
+
+	if *file != "-" {
+		fmt.Fprintf(e.stderr, "exported %d accounts to %s\n", count, *file)
+	}
+	return nil
+}
+
+func parseColumns(s string) ([]string, error) {
+	known := map[string]bool{}
+	for _, c := range exportColumns {
+		known[c] = true
+	}
+
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !known[c] {
+			return nil, usagef("unknown column %q", c)
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return nil, usagef("no columns to export")
+	}
+	return columns, nil
+}
+
+// csvWriter writes accounts as CSV records, after a header line
+func csvWriter(out io.Writer, columns []string) (write func(*accounts.Account) error, flush func() error) {
+	w := csv.NewWriter(out)
+	header := w.Write(columns)
+
+	write = func(acc *accounts.Account) error {
+		if header != nil {
+			return header
+		}
+		return w.Write(accountRecord(acc, columns))
+	}
+	flush = func() error {
+		if header != nil {
+			return header
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return write, flush
+}
+
+// ndjsonWriter writes accounts as JSON documents, one per line
+func ndjsonWriter(out io.Writer) (write func(*accounts.Account) error, flush func() error) {
+	enc := json.NewEncoder(out)
+	write = func(acc *accounts.Account) error {
+		return enc.Encode(acc)
+	}
+	return write, func() error { return nil }
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/banjoh/fake-api-client/fakeapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccounts(t *testing.T, c *cli, numbers ...string) {
+	for _, number := range numbers {
+		require.Equal(t, exitOK, c.run("create", "--country", "GB", "--bank-id", "400300", "--bic", "NWBKGB22",
+			"--account-number", number, "--name", "John Doe", "--name", "JD Ltd", "--joint-account"), c.stderr)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	c := newCLI(t)
+	createAccounts(t, c, "11111111", "22222222", "33333333")
+
+	require.Equal(t, exitOK, c.run("export", "--page-size", "2"), c.stderr)
+
+	records, err := csv.NewReader(strings.NewReader(c.stdout)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, exportColumns, records[0])
+
+	row := map[string]string{}
+	for i, col := range records[0] {
+		row[col] = records[1][i]
+	}
+	assert.Equal(t, orgID, row["organisation_id"])
+	assert.Equal(t, "0", row["version"])
+	assert.Equal(t, "11111111", row["account_number"])
+	assert.Equal(t, "John Doe;JD Ltd", row["name"])
+	assert.Equal(t, "true", row["joint_account"])
+	assert.Equal(t, "", row["iban"])
+	assert.NotEmpty(t, row["created_on"])
+
+	require.Equal(t, exitOK, c.run("export", "--columns", "id, account_number", "--account-number", "22222222"), c.stderr)
+	records, err = csv.NewReader(strings.NewReader(c.stdout)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"id", "account_number"}, records[0])
+	assert.Equal(t, "22222222", records[1][1])
+
+	assert.Equal(t, exitUsage, c.run("export", "--columns", "id,sort_code"))
+	assert.Equal(t, exitUsage, c.run("export", "--format", "xlsx"))
+}
+
+func TestExportNDJSON(t *testing.T) {
+	c := newCLI(t)
+	createAccounts(t, c, "11111111", "22222222")
+	path := filepath.Join(t.TempDir(), "accounts.ndjson")
+
+	require.Equal(t, exitOK, c.run("export", "--file", path, "--page-size", "1"), c.stderr)
+	assert.Contains(t, c.stderr, "exported 2 accounts to "+path)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var numbers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var acc map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &acc))
+		numbers = append(numbers, acc["attributes"].(map[string]interface{})["account_number"].(string))
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"11111111", "22222222"}, numbers)
+}
+
+func TestExportInterrupted(t *testing.T) {
+	c := newCLI(t)
+	createAccounts(t, c, "11111111", "22222222")
+	c.srv.SetFaults(fakeapi.FaultPlan{Rules: []fakeapi.FaultRule{
+		{Route: fakeapi.RouteList, Nth: []int{2}, Fault: fakeapi.ErrorFault(404)},
+	}})
+
+	assert.Equal(t, exitClient, c.run("export", "--page-size", "1"))
+	assert.Contains(t, c.stderr, "export interrupted after 1 accounts")
+	assert.Len(t, strings.Split(strings.TrimSpace(c.stdout), "\n"), 2, "the accounts read so far are written")
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{formatCSV, formatNDJSON} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			from := newCLI(t)
+			createAccounts(t, from, "11111111", "22222222", "33333333")
+			path := filepath.Join(t.TempDir(), "accounts."+format)
+			require.Equal(t, exitOK, from.run("export", "--file", path), from.stderr)
+
+			to := newCLI(t)
+			require.Equal(t, exitOK, to.run("import", "--file", path, "-o", "json"), to.stderr)
+			assert.Len(t, to.report(), 3)
+			assert.Equal(t, 3, to.srv.Len())
+
+			for _, res := range to.report() {
+				want, ok := from.srv.Account(res.ID)
+				require.True(t, ok)
+				got, ok := to.srv.Account(res.ID)
+				require.True(t, ok)
+				assert.Equal(t, want["attributes"], got["attributes"])
+				assert.Equal(t, want["organisation_id"], got["organisation_id"])
+			}
+		})
+	}
+}
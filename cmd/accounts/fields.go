@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banjoh/fake-api-client/accounts"
+)
+
+// listSeparator separates the values of list fields, e.g. names, in CSV files
+const listSeparator = ";"
+
+// fieldKind tells how a field is read from and written to CSV
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindList
+	kindBool
+)
+
+// attributeFields are the fields of accounts.Attributes by JSON name, the
+// names CSV columns and NDJSON keys map onto
+var attributeFields = func() map[string]fieldKind {
+	fields := map[string]fieldKind{}
+
+	t := reflect.TypeOf(accounts.Attributes{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		switch {
+		case f.Type.Kind() == reflect.Slice:
+			fields[name] = kindList
+		case f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Bool:
+			fields[name] = kindBool
+		default:
+			fields[name] = kindString
+		}
+	}
+	return fields
+}()
+
+// attributeColumns are the attribute fields in declaration order
+var attributeColumns = func() []string {
+	var columns []string
+
+	t := reflect.TypeOf(accounts.Attributes{})
+	for i := 0; i < t.NumField(); i++ {
+		columns = append(columns, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return columns
+}()
+
+const (
+	fieldID             = "id"
+	fieldOrganisationID = "organisation_id"
+)
+
+// readOnlyFields are set by the API. Import skips them so that exported
+// files can be imported again as they are
+var readOnlyFields = map[string]bool{
+	"type":        true,
+	"version":     true,
+	"created_on":  true,
+	"modified_on": true,
+}
+
+// skipField is the mapping target of source fields import ignores
+const skipField = "-"
+
+// mapping renames source fields, CSV columns or NDJSON keys, to the JSON
+// names of account fields. Fields not mapped keep their name
+type mapping map[string]string
+
+// parseMapping parses source=field pairs
+func parseMapping(pairs []string) (mapping, error) {
+	m := mapping{}
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i <= 0 || i == len(pair)-1 {
+			return nil, usagef("invalid mapping %q, expected source=field", pair)
+		}
+
+		source, target := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if err := checkTarget(target); err != nil {
+			return nil, usagef("invalid mapping %q: %v", pair, err)
+		}
+		m[source] = target
+	}
+	return m, nil
+}
+
+func (m mapping) target(source string) string {
+	if target, ok := m[source]; ok {
+		return target
+	}
+	return source
+}
+
+// checkTarget reports fields that cannot be imported
+func checkTarget(target string) error {
+	if target == skipField || target == fieldID || target == fieldOrganisationID || readOnlyFields[target] {
+		return nil
+	}
+	if _, ok := attributeFields[target]; ok {
+		return nil
+	}
+	return fmt.Errorf("unknown account field %q", target)
+}
+
+// document builds the JSON document of an account from the values of a row.
+// CSV values are strings, converted to the type of their field. Values mapped
+// onto the same list field are appended
+func (m mapping) document(keys []string, values map[string]interface{}) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	attr := map[string]interface{}{}
+
+	for _, key := range keys {
+		target := m.target(key)
+		if err := checkTarget(target); err != nil {
+			return nil, err
+		}
+		if target == skipField || readOnlyFields[target] {
+			continue
+		}
+
+		value := values[key]
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+			continue
+		}
+		if value == nil {
+			continue
+		}
+
+		if target == fieldID || target == fieldOrganisationID {
+			doc[target] = value
+			continue
+		}
+
+		converted, err := convert(attributeFields[target], value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if list, ok := converted.([]interface{}); ok {
+			if existing, ok := attr[target].([]interface{}); ok {
+				converted = append(existing, list...)
+			}
+		}
+		attr[target] = converted
+	}
+
+	if len(attr) > 0 {
+		doc["attributes"] = attr
+	}
+	return doc, nil
+}
+
+func convert(kind fieldKind, value interface{}) (interface{}, error) {
+	s, isString := value.(string)
+
+	switch kind {
+	case kindList:
+		if !isString {
+			if list, ok := value.([]interface{}); ok {
+				return list, nil
+			}
+			return nil, fmt.Errorf("expected a list, got %v", value)
+		}
+
+		var list []interface{}
+		for _, v := range strings.Split(s, listSeparator) {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+		return list, nil
+
+	case kindBool:
+		if !isString {
+			return value, nil
+		}
+
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", s)
+		}
+		return b, nil
+
+	default:
+		if isString {
+			return strings.TrimSpace(s), nil
+		}
+		return value, nil
+	}
+}
+
+// accountRecord flattens an account into CSV values, in the order of columns
+func accountRecord(acc *accounts.Account, columns []string) []string {
+	attr := map[string]interface{}{}
+	if acc.Attributes != nil {
+		data, _ := json.Marshal(acc.Attributes)
+		_ = json.Unmarshal(data, &attr)
+	}
+
+	record := make([]string, 0, len(columns))
+	for _, c := range columns {
+		var v string
+		switch c {
+		case fieldID:
+			if acc.ID != nil {
+				v = acc.ID.String()
+			}
+		case fieldOrganisationID:
+			if acc.OrganisationID != nil {
+				v = acc.OrganisationID.String()
+			}
+		case "version":
+			if acc.Version != nil {
+				v = strconv.Itoa(*acc.Version)
+			}
+		case "created_on":
+			if acc.CreatedOn != nil {
+				v = acc.CreatedOn.UTC().Format(time.RFC3339Nano)
+			}
+		case "modified_on":
+			if acc.ModifiedOn != nil {
+				v = acc.ModifiedOn.UTC().Format(time.RFC3339Nano)
+			}
+		default:
+			v = csvValue(attr[c])
+		}
+		record = append(record, v)
+	}
+	return record
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return strings.Join(values, listSeparator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/google/uuid"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	defaultConcurrency = 4
+
+	// maxNDJSONLine bounds the size of a single NDJSON document
+	maxNDJSONLine = 1 << 20
+)
+
+// importNamespace is the namespace of the IDs derived for rows without one
+var importNamespace = uuid.MustParse("6ac7dd60-e91d-4cc8-9d90-3bde8b3f8925")
+
+// Statuses of imported rows
+const (
+	statusCreated = "created"
+	statusValid   = "valid"
+	statusInvalid = "invalid"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// row is a record of the imported file. keys keeps the order of the fields
+// so that list fields are appended in a predictable order. err is set for
+// records that could not be parsed
+type row struct {
+	number int
+	keys   []string
+	values map[string]interface{}
+	err    error
+}
+
+// rowResult is the line of the import report about a row
+type rowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func runImport(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet("import", e)
+	file := fs.String("file", "", "CSV or NDJSON file to import, - for stdin")
+	format := fs.String("format", "", "format of the file: csv or ndjson, guessed from its extension when not given")
+	var mappings stringList
+	fs.Var(&mappings, "map", "maps a column or key onto an account field, e.g. 'Sort Code=bank_id', "+
+		"or '-' to skip it, repeatable. Fields are named as in the API")
+	concurrency := fs.Int("concurrency", defaultConcurrency, "number of accounts created at once")
+	dryRun := fs.Bool("dry-run", false, "validate the accounts without creating them")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected arguments: %v", positional)
+	}
+	if *file == "" {
+		return usagef("-file is required")
+	}
+	if *concurrency < 1 {
+		return usagef("concurrency must be positive: %d", *concurrency)
+	}
+
+	m, err := parseMapping(mappings)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		if *format, err = guessFormat(*file); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := g.load(e)
+	if err != nil {
+		return err
+	}
+	orgID, err := cfg.organisationID()
+	if err != nil {
+		return err
+	}
+	if _, err := newPrinter(cfg.Output, e.stdout); err != nil {
+		return err
+	}
+
+	var r *accounts.Resource
+	if !*dryRun {
+		if r, err = cfg.resource(); err != nil {
+			return err
+		}
+	}
+
+	in := e.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("failed to open input: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	imp := &importer{resource: r, mapping: m, orgID: orgID, dryRun: *dryRun}
+	results, readErr := imp.run(ctx, *format, in, *concurrency)
+
+	if err := writeReport(cfg.Output, e.stdout, results); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Status != statusCreated && res.Status != statusValid {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows were not imported", failed, len(results))
+	}
+	return nil
+}
+
+func guessFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV, nil
+	case ".ndjson", ".jsonl":
+		return formatNDJSON, nil
+	default:
+		return "", usagef("cannot tell the format of %q, set -format to csv or ndjson", path)
+	}
+}
+
+// importer creates the accounts of the rows it is given
+type importer struct {
+	resource *accounts.Resource
+	mapping  mapping
+	orgID    *uuid.UUID
+	dryRun   bool
+}
+
+// run reads the rows of in and imports them, with up to concurrency accounts
+// created at once. Results are returned in row order, along with the error
+// reading in broke off with, if any
+func (imp *importer) run(ctx context.Context, format string, in io.Reader, concurrency int) ([]rowResult, error) {
+	rows := make(chan row)
+
+	var mu sync.Mutex
+	var results []rowResult
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rows {
+				res := imp.importRow(ctx, r)
+
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var readErr error
+	switch format {
+	case formatCSV:
+		readErr = readCSV(in, imp.mapping, rows)
+	case formatNDJSON:
+		readErr = readNDJSON(in, rows)
+	default:
+		readErr = usagef("unknown input format %q, expected csv or ndjson", format)
+	}
+	close(rows)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Row < results[j].Row })
+	return results, readErr
+}
+
+func (imp *importer) importRow(ctx context.Context, r row) rowResult {
+	res := rowResult{Row: r.number}
+
+	if ctx.Err() != nil {
+		res.Status, res.Error = statusSkipped, ctx.Err().Error()
+		return res
+	}
+
+	if r.err != nil {
+		res.Status, res.Error = statusInvalid, r.err.Error()
+		return res
+	}
+
+	acc, err := imp.account(r)
+	if err != nil {
+		res.Status, res.Error = statusInvalid, err.Error()
+		return res
+	}
+	res.ID = acc.ID.String()
+
+	if imp.dryRun {
+		if err := acc.Validate(); err != nil {
+			res.Status, res.Error = statusInvalid, err.Error()
+			return res
+		}
+		res.Status = statusValid
+		return res
+	}
+
+	if _, err := imp.resource.Create(ctx, acc); err != nil {
+		var validationErr *accounts.ValidationError
+		res.Status = statusFailed
+		if errors.As(err, &validationErr) {
+			res.Status = statusInvalid
+		}
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Status = statusCreated
+	return res
+}
+
+// account maps a row onto the account to create. Accounts get the configured
+// organisation ID unless they have one. Accounts without an ID get one
+// derived from their organisation and fields, so that importing a file again
+// fails on duplicates instead of creating the same accounts twice
+func (imp *importer) account(r row) (*accounts.AccountCreate, error) {
+	doc, err := imp.mapping.document(r.keys, r.values)
+	if err != nil {
+		return nil, err
+	}
+	doc["type"] = "accounts"
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling error: %w", err)
+	}
+
+	var acc accounts.AccountCreate
+	if err := accounts.UnmarshalStrict(data, &acc); err != nil {
+		var validationErr *accounts.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unmarshaling err: %w", err)
+	}
+
+	if acc.OrganisationID == nil {
+		acc.OrganisationID = imp.orgID
+	}
+	if acc.ID == nil {
+		// The account is marshalled again so that rows of any format and
+		// field order holding the same values get the same ID
+		canonical, err := json.Marshal(&acc)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling error: %w", err)
+		}
+		id := uuid.NewSHA1(importNamespace, canonical)
+		acc.ID = &id
+	}
+	return &acc, nil
+}
+
+// readCSV sends the records of a CSV file with a header line. Columns are
+// checked against the mapping before any row is sent
+func readCSV(in io.Reader, m mapping, rows chan<- row) error {
+	reader := csv.NewReader(in)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return usagef("invalid CSV header: %v", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := map[string]bool{}
+	var problems []string
+	for _, c := range header {
+		columns[c] = true
+		if err := checkTarget(m.target(c)); err != nil {
+			problems = append(problems, fmt.Sprintf("column %q: %v", c, err))
+		}
+	}
+	for source := range m {
+		if !columns[source] {
+			problems = append(problems, fmt.Sprintf("mapped column %q not found", source))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return usagef("%s", strings.Join(problems, "; "))
+	}
+
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// A malformed record only fails its own row
+			rows <- row{number: n, err: fmt.Errorf("invalid CSV record: %w", parseErr.Err)}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		values := make(map[string]interface{}, len(header))
+		for i, c := range header {
+			values[c] = record[i]
+		}
+		rows <- row{number: n, keys: header, values: values}
+	}
+}
+
+// readNDJSON sends the JSON objects of an NDJSON file, one per line. Accounts
+// as exported or returned by the API, with nested attributes and possibly
+// a "data" envelope, are flattened
+func readNDJSON(in io.Reader, rows chan<- row) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxNDJSONLine)
+
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			rows <- row{number: n, err: fmt.Errorf("invalid JSON: %w", err)}
+			continue
+		}
+
+		if data, ok := obj["data"].(map[string]interface{}); ok && len(obj) == 1 {
+			obj = data
+		}
+		if attr, ok := obj["attributes"].(map[string]interface{}); ok {
+			delete(obj, "attributes")
+			for k, v := range attr {
+				obj[k] = v
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rows <- row{number: n, keys: keys, values: obj}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return nil
+}
+
+// writeReport writes the result of every row, as a table, a JSON array or
+// YAML
+func writeReport(format string, w io.Writer, results []rowResult) error {
+	if results == nil {
+		results = []rowResult{}
+	}
+
+	if format != formatTable {
+		p, err := newPrinter(format, w)
+		if err != nil {
+			return err
+		}
+		return p.encode(results)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROW\tSTATUS\tID\tERROR")
+	for _, res := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", res.Row, res.Status, cell(res.ID), cell(res.Error))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/banjoh/fake-api-client"
+	"github.com/banjoh/fake-api-client/accounts"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const importCSV = `Country,Sort Code,Code,Account Number,BIC,Name,Joint,Notes
+GB,400300,GBDSC,11111111,NWBKGB22,John Doe;JD Ltd,true,first
+GB,400300,GBDSC,22222222,,Jane Doe,false,missing BIC
+GB,400300,GBDSC,33333333,NWBKGB22,Jim Doe,,
+`
+
+var importMappings = []string{
+	"--map", "Country=country", "--map", "Sort Code=bank_id", "--map", "Code=bank_id_code", "--map", "Account Number=account_number",
+	"--map", "BIC=bic", "--map", "Name=name", "--map", "Joint=joint_account", "--map", "Notes=-",
+}
+
+func (c *cli) report() []rowResult {
+	var results []rowResult
+	require.NoError(c.t, json.Unmarshal([]byte(c.stdout), &results), c.stdout)
+	return results
+}
+
+func TestImportCSV(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "accounts.csv", importCSV)
+
+	code := c.run(append([]string{"import", "--file", file, "-o", "json"}, importMappings...)...)
+
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, c.stderr, "1 of 3 rows were not imported")
+
+	results := c.report()
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{statusCreated, statusInvalid, statusCreated},
+		[]string{results[0].Status, results[1].Status, results[2].Status})
+	assert.Contains(t, results[1].Error, "attributes.bic: is required for GB")
+	assert.Equal(t, 2, c.srv.Len())
+
+	acc, ok := c.srv.Account(results[0].ID)
+	require.True(t, ok)
+	attr := acc["attributes"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"John Doe", "JD Ltd"}, attr["name"])
+	assert.Equal(t, true, attr["joint_account"])
+	assert.Equal(t, orgID, acc["organisation_id"])
+}
+
+func TestImportDryRun(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "accounts.csv", importCSV)
+
+	code := c.run(append([]string{"import", "--file", file, "--dry-run"}, importMappings...)...)
+
+	assert.Equal(t, exitError, code)
+	assert.Equal(t, 0, c.srv.Len())
+	assert.Equal(t, 0, c.srv.Requests(""), "nothing is sent")
+
+	lines := strings.Split(strings.TrimSpace(c.stdout), "\n")
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^ROW\s+STATUS\s+ID\s+ERROR$`, lines[0])
+	assert.Regexp(t, `^1\s+valid\s+[0-9a-f-]{36}\s+-$`, lines[1])
+	assert.Regexp(t, `^2\s+invalid\s+[0-9a-f-]{36}\s+invalid account: attributes.bic`, lines[2])
+	assert.Regexp(t, `^3\s+valid\s+`, lines[3])
+}
+
+func TestImportTwice(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "accounts.csv", importCSV)
+	args := append([]string{"import", "--file", file, "-o", "json"}, importMappings...)
+
+	assert.Equal(t, exitError, c.run(args...))
+	first := c.report()
+	require.Len(t, first, 3)
+	require.Equal(t, 2, c.srv.Len())
+
+	assert.Equal(t, exitError, c.run(args...))
+	second := c.report()
+	require.Len(t, second, 3)
+	assert.Equal(t, 2, c.srv.Len(), "no account is created twice")
+
+	for i := range first {
+		assert.Equal(t, first[i].ID, second[i].ID, "row %d", first[i].Row)
+	}
+	assert.Equal(t, statusFailed, second[0].Status)
+	assert.Contains(t, second[0].Error, "duplicate")
+	assert.Equal(t, statusInvalid, second[1].Status)
+	assert.Equal(t, statusFailed, second[2].Status)
+
+	c.stdin = `{"name": ["John Doe", "JD Ltd"], "joint_account": true, "account_number": "11111111", "bic": "NWBKGB22", ` +
+		`"bank_id_code": "GBDSC", "bank_id": "400300", "country": "GB"}`
+	assert.Equal(t, exitOK, c.run("import", "--file", "-", "--format", "ndjson", "--dry-run", "-o", "json"), c.stderr)
+	ndjson := c.report()
+	require.Len(t, ndjson, 1)
+	assert.Equal(t, first[0].ID, ndjson[0].ID, "the same account in another format gets the same ID")
+
+	c.vars["ACCOUNTS_ORGANISATION_ID"] = uuid.New().String()
+	assert.Equal(t, exitError, c.run(args...))
+	other := c.report()
+	require.Len(t, other, 3)
+	assert.NotEqual(t, first[0].ID, other[0].ID, "IDs depend on the organisation")
+	assert.Equal(t, statusCreated, other[0].Status, other[0].Error)
+}
+
+func TestImportNDJSON(t *testing.T) {
+	c := newCLI(t)
+	existing := uuid.New().String()
+	require.Equal(t, exitOK, c.run("create", "--id", existing, "--country", "GB", "--bank-id", "400300",
+		"--bic", "NWBKGB22", "--name", "John Doe"), c.stderr)
+
+	c.stdin = `{"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["Jane Doe"], "customer": "c-1"}
+
+{"data": {"type": "accounts", "attributes": {"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["Jim Doe"]}}}
+{"country": "GB",
+{"id": "not-a-uuid", "country": "GB"}
+`
+	code := c.run("import", "--file", "-", "--format", "ndjson", "--map", "customer=customer_id", "-o", "json")
+
+	assert.Equal(t, exitError, code)
+	results := c.report()
+	require.Len(t, results, 4)
+	assert.Equal(t, []int{1, 3, 4, 5}, []int{results[0].Row, results[1].Row, results[2].Row, results[3].Row},
+		"rows are line numbers")
+	assert.Equal(t, statusCreated, results[0].Status, results[0].Error)
+	assert.Equal(t, statusCreated, results[1].Status, results[1].Error)
+	assert.Equal(t, statusInvalid, results[2].Status)
+	assert.Contains(t, results[2].Error, "invalid JSON")
+	assert.Equal(t, statusInvalid, results[3].Status)
+
+	acc, ok := c.srv.Account(results[0].ID)
+	require.True(t, ok)
+	assert.Equal(t, "c-1", acc["attributes"].(map[string]interface{})["customer_id"])
+
+	c.stdin = `{"data": {"id": "` + existing + `", "attributes": {"country": "GB", "bank_id": "400300", ` +
+		`"bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["John Doe"]}}}`
+	assert.Equal(t, exitError, c.run("import", "--file", "-", "--format", "ndjson", "-o", "json"))
+	results = c.report()
+	require.Len(t, results, 1)
+	assert.Equal(t, statusFailed, results[0].Status)
+	assert.Contains(t, results[0].Error, "duplicate")
+}
+
+func TestImportUnknownEnum(t *testing.T) {
+	c := newCLI(t)
+	c.stdin = `{"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22", "name": ["Jane Doe"], "status": "closed"}`
+
+	assert.Equal(t, exitError, c.run("import", "--file", "-", "--format", "ndjson", "-o", "json"))
+	results := c.report()
+	require.Len(t, results, 1)
+	assert.Equal(t, statusInvalid, results[0].Status)
+	assert.Equal(t, `invalid account: attributes.status: unknown value "closed"`, results[0].Error)
+	assert.Equal(t, 0, c.srv.Len())
+}
+
+func TestImportUsage(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "accounts.csv", importCSV)
+
+	tests := map[string]struct {
+		args   []string
+		stderr string
+	}{
+		"missing file":        {args: []string{}, stderr: "-file is required"},
+		"unknown format":      {args: []string{"--file", writeFile(t, "accounts.txt", "")}, stderr: "set -format"},
+		"unmapped columns":    {args: []string{"--file", file}, stderr: `column "Sort Code": unknown account field "Sort Code"`},
+		"unknown target":      {args: []string{"--file", file, "--map", "Notes=remarks"}, stderr: `unknown account field "remarks"`},
+		"missing column":      {args: append([]string{"--file", file, "--map", "IBAN=iban"}, importMappings...), stderr: `mapped column "IBAN" not found`},
+		"invalid mapping":     {args: []string{"--file", file, "--map", "Notes"}, stderr: "expected source=field"},
+		"invalid concurrency": {args: []string{"--file", file, "--concurrency", "0"}, stderr: "concurrency must be positive"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, exitUsage, c.run(append([]string{"import"}, tc.args...)...))
+			assert.Contains(t, c.stderr, tc.stderr)
+			assert.Equal(t, 0, c.srv.Len())
+		})
+	}
+}
+
+func TestImportConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inflight, maxInflight := 0, 0
+
+	mock := &client.MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(bytes.NewReader(body))}, nil
+	}}
+	r, err := accounts.NewWithClient(mock, &client.MockRetrySleeper{})
+	require.NoError(t, err)
+
+	var csv strings.Builder
+	csv.WriteString("country,bank_id,bank_id_code,bic,name\n")
+	for i := 0; i < 40; i++ {
+		csv.WriteString("GB,400300,GBDSC,NWBKGB22,John Doe\n")
+	}
+
+	org := uuid.MustParse(orgID)
+	imp := &importer{resource: r, mapping: mapping{}, orgID: &org}
+	results, err := imp.run(context.Background(), formatCSV, strings.NewReader(csv.String()), 4)
+
+	require.NoError(t, err)
+	require.Len(t, results, 40)
+	for i, res := range results {
+		assert.Equal(t, i+1, res.Row, "results are in row order")
+		assert.Equal(t, statusCreated, res.Status, res.Error)
+	}
+	assert.LessOrEqual(t, maxInflight, 4)
+	assert.Greater(t, maxInflight, 1)
+}
+
+func TestImportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	imp := &importer{mapping: mapping{}, dryRun: true}
+	results, err := imp.run(ctx, formatCSV, strings.NewReader("country\nGB\nFR\n"), 2)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, statusSkipped, results[0].Status)
+	assert.Equal(t, statusSkipped, results[1].Status)
+}
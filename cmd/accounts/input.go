@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/banjoh/fake-api-client/accounts"
+	"gopkg.in/yaml.v3"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// boolFlag is a bool flag telling whether it was set at all
+type boolFlag struct {
+	set   bool
+	value bool
+}
+
+func (b *boolFlag) String() string {
+	if b == nil || !b.set {
+		return ""
+	}
+	return fmt.Sprint(b.value)
+}
+
+func (b *boolFlag) Set(v string) error {
+	switch strings.ToLower(v) {
+	case "true", "1", "yes":
+		b.value = true
+	case "false", "0", "no":
+		b.value = false
+	default:
+		return fmt.Errorf("invalid boolean %q", v)
+	}
+	b.set = true
+	return nil
+}
+
+func (b *boolFlag) IsBoolFlag() bool { return true }
+
+// ptr returns the flag value, nil when not set
+func (b *boolFlag) ptr() *bool {
+	if !b.set {
+		return nil
+	}
+	v := b.value
+	return &v
+}
+
+// readFile reads a JSON or YAML document into v, from stdin when path is "-".
+// Documents may be wrapped in a JSON:API "data" envelope. Unknown fields and
+// enum values are rejected so that typos do not go unnoticed
+func readFile(path string, stdin io.Reader, v interface{}) error {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	// JSON is YAML, a single parser reads both
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return usagef("invalid input file %s: %v", path, err)
+	}
+	root := &node
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind == yaml.MappingNode && len(root.Content) == 2 && root.Content[0].Value == "data" {
+		root = root.Content[1]
+	}
+	if root.Kind != yaml.MappingNode {
+		return usagef("invalid input file %s: expected an object", path)
+	}
+
+	doc, err := yamlValue(root, reflect.TypeOf(v))
+	if err != nil {
+		return usagef("invalid input file %s: %v", path, err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return usagef("invalid input file %s: %v", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return usagef("invalid input file %s: %v", path, err)
+	}
+	return accounts.CheckEnums(v)
+}
+
+// yamlValue converts a YAML node into the value json.Marshal encodes as the
+// JSON document t is decoded from. Plain scalars read into string fields keep
+// their source text, so that account numbers or sort codes such as 040004
+// need no quotes
+func yamlValue(n *yaml.Node, t reflect.Type) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		return yamlValue(n.Alias, t)
+
+	case yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		if t != nil && t.Kind() == reflect.Struct {
+			for i := 0; i < t.NumField(); i++ {
+				if f := t.Field(i); f.PkgPath == "" {
+					fields[strings.Split(f.Tag.Get("json"), ",")[0]] = f.Type
+				}
+			}
+		}
+
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlValue(n.Content[i+1], fields[n.Content[i].Value])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+
+		l := make([]interface{}, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := yamlValue(c, elem)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		return l, nil
+
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!null" {
+			return nil, nil
+		}
+		if t != nil && t.Kind() == reflect.String {
+			return n.Value, nil
+		}
+
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+
+	default:
+		return nil, fmt.Errorf("line %d: unexpected YAML node", n.Line)
+	}
+}
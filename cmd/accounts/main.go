@@ -0,0 +1,162 @@
+// Command accounts manages the accounts of the Fake API from the command line.
+//
+//	accounts create --country GB --bank-id 400300 --account-number 41426819 --name "John Doe"
+//	accounts create --file account.yaml
+//	accounts get ad27e265-9605-4b4b-a0e5-3003ea9cc4dc -o yaml
+//	accounts list --country GB --all
+//	accounts update ad27e265-9605-4b4b-a0e5-3003ea9cc4dc --name "Jane Doe"
+//	accounts delete ad27e265-9605-4b4b-a0e5-3003ea9cc4dc
+//	accounts import --file accounts.csv --map "Sort Code=bank_id" --dry-run
+//	accounts export --file accounts.ndjson
+//
+// The base URL and authentication are configured through flags, ACCOUNTS_*
+// environment variables or a YAML config file, in decreasing precedence.
+// The exit code tells how a command failed:
+// * 1 for any other error, e.g. an account failing validation
+// * 2 for usage errors
+// * 3 for network errors, timeouts included
+// * 4 for 4xx responses of the API
+// * 5 for 5xx responses of the API
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	client "github.com/banjoh/fake-api-client"
+)
+
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitNetwork = 3
+	exitClient  = 4
+	exitServer  = 5
+)
+
+// env is what commands interact with, swapped out by tests
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+type command struct {
+	synopsis string
+	summary  string
+	run      func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"create": {"create [flags]", "Create an account from flags or a JSON/YAML file", runCreate},
+	"get":    {"get <id> [flags]", "Fetch an account", runGet},
+	"list":   {"list [flags]", "List accounts, a page or all of them", runList},
+	"update": {"update <id> [flags]", "Update the attributes of an account", runUpdate},
+	"delete": {"delete <id> [flags]", "Delete an account", runDelete},
+	"import": {"import -file <file> [flags]", "Create accounts from a CSV or NDJSON file", runImport},
+	"export": {"export [flags]", "Write every account to CSV or NDJSON", runExport},
+}
+
+// usageError reports a command line that cannot be run
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command line and returns the exit code
+func run(ctx context.Context, e *env, args []string) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitUsage
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(e.stdout)
+		return exitOK
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(e.stderr, "accounts: unknown command %q\n\n", name)
+		usage(e.stderr)
+		return exitUsage
+	}
+
+	err := cmd.run(ctx, e, args[1:])
+	if errors.Is(err, errHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "accounts %s: %v\n", name, err)
+	}
+	return exitCode(err)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: accounts <command> [flags]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-28s %s\n", commands[name].synopsis, commands[name].summary)
+	}
+
+	fmt.Fprintf(w, "\nRun 'accounts <command> -h' for the flags of a command.\n")
+}
+
+// exitCode maps an error to the exit code documented in the package comment
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode >= 400 && apiErr.StatusCode < 500:
+			return exitClient
+		case apiErr.StatusCode >= 500:
+			return exitServer
+		}
+		return exitError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return exitNetwork
+	}
+
+	return exitError
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/banjoh/fake-api-client/fakeapi"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const orgID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+
+type cli struct {
+	t      *testing.T
+	srv    *fakeapi.Server
+	vars   map[string]string
+	stdin  string
+	stdout string
+	stderr string
+}
+
+func newCLI(t *testing.T) *cli {
+	srv := fakeapi.NewServer()
+	t.Cleanup(srv.Close)
+
+	return &cli{t: t, srv: srv, vars: map[string]string{
+		"ACCOUNTS_BASE_URL":        srv.URL,
+		"ACCOUNTS_ORGANISATION_ID": orgID,
+	}}
+}
+
+func (c *cli) run(args ...string) int {
+	var stdout, stderr bytes.Buffer
+	e := &env{
+		stdin:  strings.NewReader(c.stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(k string) string { return c.vars[k] },
+	}
+
+	code := run(context.Background(), e, args)
+	c.stdout, c.stderr = stdout.String(), stderr.String()
+	return code
+}
+
+func (c *cli) account() map[string]interface{} {
+	var acc map[string]interface{}
+	require.NoError(c.t, json.Unmarshal([]byte(c.stdout), &acc), c.stdout)
+	return acc
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	c := newCLI(t)
+
+	require.Equal(t, exitOK, c.run("create", "--country", "GB", "--bank-id", "400300", "--account-number", "41426819",
+		"--bic", "NWBKGB22", "--name", "John Doe", "--name", "JD Ltd", "-o", "json"), c.stderr)
+	created := c.account()
+	id := created["id"].(string)
+	assert.Equal(t, orgID, created["organisation_id"])
+	attr := created["attributes"].(map[string]interface{})
+	assert.Equal(t, "GBDSC", attr["bank_id_code"], "country defaults are filled in")
+	assert.Equal(t, "GBP", attr["base_currency"])
+	assert.Equal(t, []interface{}{"John Doe", "JD Ltd"}, attr["name"])
+
+	require.Equal(t, exitOK, c.run("get", id, "-output", "yaml"), c.stderr)
+	var fetched map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(c.stdout), &fetched))
+	assert.Equal(t, id, fetched["id"])
+	assert.Contains(t, c.stdout, `account_number: "41426819"`)
+
+	require.Equal(t, exitOK, c.run("update", id, "--name", "Jane Doe", "--status", "confirmed", "-o", "json"), c.stderr)
+	updated := c.account()
+	assert.Equal(t, float64(1), updated["version"])
+	attr = updated["attributes"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"Jane Doe"}, attr["name"])
+	assert.Equal(t, "confirmed", attr["status"])
+	assert.Equal(t, "400300", attr["bank_id"], "attributes not given are left untouched")
+
+	assert.Equal(t, exitClient, c.run("update", id, "--version", "0", "--name", "Stale"))
+	assert.Contains(t, c.stderr, "version conflict")
+
+	require.Equal(t, exitOK, c.run("get", id), c.stderr)
+	lines := strings.Split(strings.TrimSpace(c.stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^ID\s+VERSION\s+COUNTRY\s+BANK ID\s+BIC\s+ACCOUNT NUMBER\s+IBAN\s+NAME\s+STATUS$`, lines[0])
+	assert.Regexp(t, id+`\s+1\s+GB\s+400300\s+NWBKGB22\s+41426819\s+-\s+Jane Doe\s+confirmed`, lines[1])
+
+	require.Equal(t, exitOK, c.run("delete", id), c.stderr)
+	assert.Empty(t, c.stdout)
+	assert.Equal(t, 0, c.srv.Len())
+
+	assert.Equal(t, exitClient, c.run("get", id))
+}
+
+func TestCreateFromFile(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := map[string]struct {
+		file  string
+		stdin bool
+		args  []string
+	}{
+		"yaml": {
+			file: `
+attributes:
+  country: GB
+  bank_id: 400300
+  bank_id_code: GBDSC
+  bic: NWBKGB22
+  name: [John Doe]
+`,
+		},
+		"json envelope": {
+			file: `{"data": {"id": "` + id + `", "type": "accounts", "organisation_id": "` + orgID + `",
+				"attributes": {"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC", "bic": "NWBKGB22",
+				"name": ["John Doe"]}}}`,
+		},
+		"stdin with flag overrides": {
+			file: `
+attributes:
+  country: GB
+  bank_id: "000000"
+  bank_id_code: GBDSC
+  name: [John Doe]
+`,
+			stdin: true,
+			args:  []string{"--bank-id", "400300", "--bic", "NWBKGB22"},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			c := newCLI(t)
+
+			args := []string{"create", "-o", "json", "--id", id}
+			if tc.stdin {
+				c.stdin = tc.file
+				args = append(args, "--file", "-")
+			} else {
+				args = append(args, "--file", writeFile(t, "account", tc.file))
+			}
+
+			require.Equal(t, exitOK, c.run(append(args, tc.args...)...), c.stderr)
+			acc := c.account()
+			assert.Equal(t, id, acc["id"])
+			assert.Equal(t, orgID, acc["organisation_id"])
+			attr := acc["attributes"].(map[string]interface{})
+			assert.Equal(t, "400300", attr["bank_id"])
+			assert.Equal(t, "NWBKGB22", attr["bic"])
+		})
+	}
+}
+
+func TestCreateFromFileUnquotedYAML(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "account.yaml", `
+data:
+  version: 0
+  attributes:
+    country: GB
+    bank_id: 040004
+    bank_id_code: GBDSC
+    bic: NWBKGB22
+    account_number: 41426819
+    name: [John Doe]
+    joint_account: true
+    customer_id: 1e3
+`)
+
+	require.Equal(t, exitOK, c.run("create", "--file", file, "-o", "json"), c.stderr)
+	attr := c.account()["attributes"].(map[string]interface{})
+	assert.Equal(t, "040004", attr["bank_id"])
+	assert.Equal(t, "41426819", attr["account_number"])
+	assert.Equal(t, "1e3", attr["customer_id"])
+	assert.Equal(t, true, attr["joint_account"])
+
+	file = writeFile(t, "version.yaml", "version: zero\nattributes: {country: GB}\n")
+	assert.Equal(t, exitUsage, c.run("create", "--file", file))
+}
+
+func TestCreateFromFileUnknownEnum(t *testing.T) {
+	c := newCLI(t)
+	file := writeFile(t, "account.yaml", `
+attributes:
+  country: GB
+  bank_id: "400300"
+  bank_id_code: GBDSC
+  bic: NWBKGB22
+  name: [John Doe]
+  status: closed
+`)
+
+	assert.Equal(t, exitError, c.run("create", "--file", file))
+	assert.Contains(t, c.stderr, `attributes.status: unknown value "closed"`)
+	assert.Equal(t, 0, c.srv.Len())
+}
+
+func TestDeleteWithoutVersion(t *testing.T) {
+	id := uuid.New().String()
+	deleted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"type": "accounts", "id": "%s", "attributes": {"country": "GB"}}}`, id)
+	}))
+	defer srv.Close()
+
+	c := newCLI(t)
+	c.vars["ACCOUNTS_BASE_URL"] = srv.URL
+
+	assert.Equal(t, exitError, c.run("delete", id))
+	assert.Contains(t, c.stderr, "set -version")
+	assert.False(t, deleted)
+
+	assert.Equal(t, exitOK, c.run("delete", id, "--version", "0"), c.stderr)
+	assert.True(t, deleted)
+}
+
+func TestList(t *testing.T) {
+	c := newCLI(t)
+	for _, number := range []string{"11111111", "22222222", "33333333"} {
+		require.Equal(t, exitOK, c.run("create", "--country", "GB", "--bank-id", "400300",
+			"--bic", "NWBKGB22", "--account-number", number, "--name", "John Doe"), c.stderr)
+	}
+	require.Equal(t, exitOK, c.run("create", "--country", "DE", "--bank-id", "37040044",
+		"--account-number", "1234567", "--name", "Hans Muster"), c.stderr)
+
+	tests := map[string]struct {
+		args  []string
+		count int
+	}{
+		"first page":     {args: []string{"--page-size", "2"}, count: 2},
+		"all pages":      {args: []string{"--page-size", "2", "--all"}, count: 4},
+		"filter":         {args: []string{"--country", "DE"}, count: 1},
+		"repeat filters": {args: []string{"--account-number", "11111111", "--account-number", "33333333"}, count: 2},
+		"none matching":  {args: []string{"--country", "FR"}, count: 0},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, exitOK, c.run(append([]string{"list", "-o", "json"}, tc.args...)...), c.stderr)
+
+			var list []map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(c.stdout), &list), c.stdout)
+			assert.Len(t, list, tc.count)
+
+			require.Equal(t, exitOK, c.run(append([]string{"list"}, tc.args...)...), c.stderr)
+			lines := strings.Split(strings.TrimSpace(c.stdout), "\n")
+			if tc.count == 0 {
+				assert.Equal(t, []string{""}, lines)
+			} else {
+				assert.Len(t, lines, tc.count+1)
+			}
+		})
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	c := newCLI(t)
+
+	tests := map[string]struct {
+		args   []string
+		faults []fakeapi.FaultRule
+		vars   map[string]string
+		code   int
+		stderr string
+	}{
+		"help":              {args: []string{"help"}, code: exitOK},
+		"command help":      {args: []string{"get", "-h"}, code: exitOK},
+		"no command":        {args: nil, code: exitUsage},
+		"unknown command":   {args: []string{"fetch"}, code: exitUsage, stderr: `unknown command "fetch"`},
+		"unknown flag":      {args: []string{"list", "--colour"}, code: exitUsage},
+		"missing id":        {args: []string{"get"}, code: exitUsage},
+		"invalid id":        {args: []string{"get", "42"}, code: exitUsage, stderr: `invalid account ID "42"`},
+		"unknown output":    {args: []string{"list", "-o", "xml"}, code: exitUsage},
+		"nothing to update": {args: []string{"update", uuid.New().String()}, code: exitUsage},
+		"missing input":     {args: []string{"create"}, code: exitUsage},
+		"invalid base url":  {args: []string{"list"}, vars: map[string]string{"ACCOUNTS_BASE_URL": "ftp://x"}, code: exitUsage},
+		"invalid account":   {args: []string{"create", "--country", "GB", "--name", "John"}, code: exitError, stderr: "invalid account"},
+		"not found":         {args: []string{"get", uuid.New().String()}, code: exitClient, stderr: "does not exist"},
+		"invalid version":   {args: []string{"delete", uuid.New().String(), "--version", "x"}, code: exitUsage},
+		"server error": {
+			args:   []string{"list"},
+			faults: []fakeapi.FaultRule{{Route: fakeapi.RouteList, Fault: fakeapi.EmptyBodyFault(http.StatusNotImplemented)}},
+			code:   exitServer,
+		},
+		"network error": {
+			args: []string{"list"},
+			vars: map[string]string{"ACCOUNTS_BASE_URL": "http://127.0.0.1:1"},
+			code: exitNetwork,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			c.srv.SetFaults(fakeapi.FaultPlan{Rules: tc.faults})
+			defer c.srv.ClearFaults()
+
+			saved := c.vars
+			c.vars = map[string]string{}
+			for k, v := range saved {
+				c.vars[k] = v
+			}
+			for k, v := range tc.vars {
+				c.vars[k] = v
+			}
+			defer func() { c.vars = saved }()
+
+			assert.Equal(t, tc.code, c.run(tc.args...), c.stderr)
+			assert.Contains(t, c.stderr, tc.stderr)
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	var tokenHeaders http.Header
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenHeaders = r.Header.Clone()
+		fmt.Fprint(w, `{"access_token": "secret-token", "token_type": "bearer", "expires_in": 3600}`)
+	}))
+	defer tokens.Close()
+
+	c := newCLI(t)
+	var apiHeaders http.Header
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiHeaders = r.Header.Clone()
+		c.srv.ServeHTTP(w, r)
+	}))
+	defer api.Close()
+
+	c.vars["ACCOUNTS_BASE_URL"] = api.URL
+	c.vars["ACCOUNTS_TOKEN_URL"] = tokens.URL
+	c.vars["ACCOUNTS_CLIENT_ID"] = "cli"
+	c.vars["ACCOUNTS_SIGNING_KEY_ID"] = "key-1"
+	c.vars["ACCOUNTS_SIGNING_KEY_FILE"] = filepath.Join("..", "..", "testdata", "signing_ed25519.pem")
+
+	assert.Equal(t, exitOK, c.run("list"), c.stderr)
+
+	require.NotNil(t, tokenHeaders)
+	assert.Empty(t, tokenHeaders.Get("Signature"), "token requests are not signed")
+	require.NotNil(t, apiHeaders)
+	assert.Equal(t, "Bearer secret-token", apiHeaders.Get("Authorization"))
+	assert.Contains(t, apiHeaders.Get("Signature"), `keyId="key-1"`)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	file := writeFile(t, "accounts.yaml", `
+base_url: https://file.example.com
+organisation_id: 11111111-1111-1111-1111-111111111111
+output: yaml
+timeout: 10s
+auth:
+  token_url: https://auth.example.com/token
+  client_id: file-client
+  scopes: [accounts:read]
+`)
+
+	tests := map[string]struct {
+		args []string
+		vars map[string]string
+		want config
+	}{
+		"config file": {
+			args: []string{"--config", file},
+			want: config{
+				BaseURL: "https://file.example.com", OrganisationID: "11111111-1111-1111-1111-111111111111",
+				Output: "yaml", Timeout: "10s",
+				Auth: auth{TokenURL: "https://auth.example.com/token", ClientID: "file-client", Scopes: []string{"accounts:read"}},
+			},
+		},
+		"environment overrides the file": {
+			vars: map[string]string{
+				"ACCOUNTS_CONFIG": file, "ACCOUNTS_BASE_URL": "https://env.example.com",
+				"ACCOUNTS_CLIENT_SECRET": "env-secret", "ACCOUNTS_SCOPES": "a b",
+			},
+			want: config{
+				BaseURL: "https://env.example.com", OrganisationID: "11111111-1111-1111-1111-111111111111",
+				Output: "yaml", Timeout: "10s",
+				Auth: auth{
+					TokenURL: "https://auth.example.com/token", ClientID: "file-client", ClientSecret: "env-secret",
+					Scopes: []string{"a", "b"},
+				},
+			},
+		},
+		"flags override the environment": {
+			args: []string{"--base-url", "https://flag.example.com", "-o", "json", "--scope", "c"},
+			vars: map[string]string{"ACCOUNTS_CONFIG": file, "ACCOUNTS_BASE_URL": "https://env.example.com"},
+			want: config{
+				BaseURL: "https://flag.example.com", OrganisationID: "11111111-1111-1111-1111-111111111111",
+				Output: "json", Timeout: "10s",
+				Auth: auth{TokenURL: "https://auth.example.com/token", ClientID: "file-client", Scopes: []string{"c"}},
+			},
+		},
+		"default config file": {
+			vars: map[string]string{"XDG_CONFIG_HOME": filepath.Join(t.TempDir(), "missing")},
+			want: config{Output: formatTable},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			e := &env{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, getenv: func(k string) string { return tc.vars[k] }}
+			fs, g := newFlagSet("test", e)
+			_, err := parseArgs(fs, tc.args)
+			require.NoError(t, err)
+
+			cfg, err := g.load(e)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, *cfg)
+		})
+	}
+
+	e := &env{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }}
+	fs, g := newFlagSet("test", e)
+	_, err := parseArgs(fs, []string{"--config", filepath.Join(t.TempDir(), "missing.yaml")})
+	require.NoError(t, err)
+	_, err = g.load(e)
+	assert.Error(t, err, "an explicit config file must exist")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/banjoh/fake-api-client/accounts"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// printer writes accounts in the requested output format. Tables are written
+// as accounts come, JSON and YAML lists once complete
+type printer struct {
+	format string
+	w      io.Writer
+	tw     *tabwriter.Writer
+	list   []*accounts.Account
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return &printer{format: format, w: w}, nil
+	default:
+		return nil, usagef("unknown output format %q, expected table, json or yaml", format)
+	}
+}
+
+// account writes a single account
+func (p *printer) account(acc *accounts.Account) error {
+	if p.format == formatTable {
+		p.add(acc)
+		return p.flush()
+	}
+	return p.encode(acc)
+}
+
+// add appends an account to a list, see flush
+func (p *printer) add(acc *accounts.Account) {
+	if p.format != formatTable {
+		p.list = append(p.list, acc)
+		return
+	}
+
+	if p.tw == nil {
+		p.tw = tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(p.tw, "ID\tVERSION\tCOUNTRY\tBANK ID\tBIC\tACCOUNT NUMBER\tIBAN\tNAME\tSTATUS")
+	}
+
+	version := ""
+	if acc.Version != nil {
+		version = strconv.Itoa(*acc.Version)
+	}
+	id := ""
+	if acc.ID != nil {
+		id = acc.ID.String()
+	}
+	attr := acc.Attributes
+	if attr == nil {
+		attr = &accounts.Attributes{}
+	}
+
+	fmt.Fprintf(p.tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, version, cell(attr.Country), cell(attr.BankID),
+		cell(attr.BIC), cell(attr.AccountNumber), cell(attr.IBAN), cell(strings.Join(attr.Name, ", ")),
+		cell(string(attr.Status)))
+}
+
+// flush writes the accounts added so far
+func (p *printer) flush() error {
+	if p.format != formatTable {
+		list := p.list
+		if list == nil {
+			list = []*accounts.Account{}
+		}
+		return p.encode(list)
+	}
+
+	if p.tw == nil {
+		return nil
+	}
+	return p.tw.Flush()
+}
+
+func (p *printer) encode(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling error: %w", err)
+	}
+
+	if p.format == formatJSON {
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	}
+
+	// Going through JSON keeps the field names and order of the API
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("marshalling error: %w", err)
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("marshalling error: %w", err)
+	}
+	_, err = buf.WriteTo(p.w)
+	return err
+}
+
+// blockStyle drops the flow style JSON documents are parsed with
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func cell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"time"
 )
 
 // APIError is used to encapsulate all API errors a server
@@ -30,3 +31,22 @@ func (e *APIError) Is(target error) bool {
 		e.ErrorCode == t.ErrorCode &&
 		e.StatusCode == t.StatusCode
 }
+
+// RateLimitedError is returned when the server kept answering 429 Too Many
+// Requests until the retry attempts ran out, or asked for a longer wait than
+// the retry policy allows. RateLimit holds the hints of the last response.
+// The underlying APIError is available through errors.As
+type RateLimitedError struct {
+	RateLimit RateLimit
+	Err       *APIError
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited: retry_after=%s, remaining=%d, reset=%s: %v",
+		e.RateLimit.RetryAfter, e.RateLimit.Remaining, e.RateLimit.Reset.Format(time.RFC3339), e.Err,
+	)
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return e.Err
+}
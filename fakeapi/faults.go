@@ -0,0 +1,269 @@
+package fakeapi
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route identifies an endpoint of the API faults can be limited to
+type Route string
+
+const (
+	// RouteAny matches every request
+	RouteAny    Route = ""
+	RouteCreate Route = "create"
+	RouteList   Route = "list"
+	RouteFetch  Route = "fetch"
+	RouteUpdate Route = "update"
+	RouteDelete Route = "delete"
+	RouteOther  Route = "other"
+)
+
+// Fault describes how a request is failed. Status, Reset and Truncate are
+// exclusive, Delay can be combined with any of them or used on its own to
+// slow responses down
+type Fault struct {
+	// Status answers the request with this status code and an error body,
+	// without handling it
+	Status int
+
+	// RetryAfter sets the Retry-After header of Status responses, rounded
+	// up to the second
+	RetryAfter time.Duration
+
+	// EmptyBody leaves out the error body of Status responses
+	EmptyBody bool
+
+	// Reset closes the connection without answering, nor handling the request
+	Reset bool
+
+	// Truncate handles the request and cuts the body of its response in half
+	Truncate bool
+
+	// Delay holds the request this long before failing or handling it.
+	// Requests given up by the client in the meantime are dropped
+	Delay time.Duration
+}
+
+// ErrorFault answers with the given status code and an error body
+func ErrorFault(status int) Fault {
+	return Fault{Status: status}
+}
+
+// RateLimitFault answers 429 Too Many Requests asking to retry after d
+func RateLimitFault(d time.Duration) Fault {
+	return Fault{Status: http.StatusTooManyRequests, RetryAfter: d}
+}
+
+// EmptyBodyFault answers with the given status code and no body at all
+func EmptyBodyFault(status int) Fault {
+	return Fault{Status: status, EmptyBody: true}
+}
+
+// ResetFault drops the connection without answering. Over HTTP/2 the
+// request's stream is reset instead, leaving the connection open
+func ResetFault() Fault {
+	return Fault{Reset: true}
+}
+
+// DelayFault answers normally, after d
+func DelayFault(d time.Duration) Fault {
+	return Fault{Delay: d}
+}
+
+// TruncateFault handles the request but answers with half of the response
+// body, e.g. a JSON document cut short
+func TruncateFault() Fault {
+	return Fault{Truncate: true}
+}
+
+// FaultRule injects a fault into the requests of a route. The requests to
+// fail are picked by their position among the requests of the route, 1 being
+// the first, or else randomly with the given probability. A rule with
+// neither fails every request of its route
+type FaultRule struct {
+	Route       Route
+	Nth         []int
+	Probability float64
+	Fault       Fault
+}
+
+// FaultPlan is the set of faults a Server injects. The first rule picking a
+// request fails it. Seed makes the random picks reproducible
+type FaultPlan struct {
+	Rules []FaultRule
+	Seed  int64
+}
+
+// Requests returns the request numbers from, to included, for FaultRule.Nth,
+// e.g. Requests(1, 3) for a burst failing the first three requests
+func Requests(from, to int) []int {
+	var n []int
+	for i := from; i <= to; i++ {
+		n = append(n, i)
+	}
+	return n
+}
+
+// faultState is a FaultPlan being applied
+type faultState struct {
+	plan   FaultPlan
+	counts []int
+	rand   *rand.Rand
+}
+
+// SetFaults replaces the faults injected by the server. Request counts of
+// the rules start over
+func (s *Server) SetFaults(plan FaultPlan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan.Rules = append([]FaultRule(nil), plan.Rules...)
+	s.faults = &faultState{
+		plan:   plan,
+		counts: make([]int, len(plan.Rules)),
+		rand:   rand.New(rand.NewSource(plan.Seed)), // nolint: gosec
+	}
+}
+
+// ClearFaults stops injecting faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns how many requests the route received, failed ones included
+func (s *Server) Requests(route Route) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if route == RouteAny {
+		total := 0
+		for _, n := range s.requests {
+			total += n
+		}
+		return total
+	}
+	return s.requests[route]
+}
+
+// pickFault counts the request and returns the fault to inject, if any
+func (s *Server) pickFault(route Route) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[route]++
+
+	if s.faults == nil {
+		return Fault{}, false
+	}
+
+	picked := -1
+	for i, rule := range s.faults.plan.Rules {
+		if rule.Route != RouteAny && rule.Route != route {
+			continue
+		}
+		s.faults.counts[i]++
+
+		if picked >= 0 {
+			continue
+		}
+
+		switch {
+		case len(rule.Nth) > 0:
+			for _, n := range rule.Nth {
+				if n == s.faults.counts[i] {
+					picked = i
+				}
+			}
+		case rule.Probability > 0:
+			if s.faults.rand.Float64() < rule.Probability {
+				picked = i
+			}
+		default:
+			picked = i
+		}
+	}
+
+	if picked < 0 {
+		return Fault{}, false
+	}
+	return s.faults.plan.Rules[picked].Fault, true
+}
+
+// inject fails the request as described by f. handled is false when the
+// request is still to be answered normally
+func (s *Server) inject(w http.ResponseWriter, req *http.Request, f Fault) (handled bool) {
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return true
+		}
+	}
+
+	switch {
+	case f.Reset:
+		reset(w)
+		return true
+
+	case f.Status != 0:
+		if f.RetryAfter > 0 {
+			secs := (f.RetryAfter + time.Second - 1) / time.Second
+			w.Header().Set("Retry-After", strconv.Itoa(int(secs)))
+		}
+		if f.EmptyBody {
+			w.WriteHeader(f.Status)
+			return true
+		}
+		writeError(w, f.Status, strings.ToLower(http.StatusText(f.Status)))
+		return true
+
+	case f.Truncate:
+		rec := httptest.NewRecorder()
+		s.route(rec, req)
+
+		body := rec.Body.Bytes()
+		body = body[:len(body)/2]
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.Code)
+		_, _ = bytes.NewReader(body).WriteTo(w)
+		return true
+
+	default:
+		return false
+	}
+}
+
+// reset closes the connection abruptly, making the client's read fail with
+// a connection reset rather than a clean end of stream. HTTP/2 connections
+// cannot be hijacked, only the stream of the request is reset then
+func reset(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	conn.Close()
+}
@@ -0,0 +1,197 @@
+package fakeapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetchStatuses(t *testing.T, srv *Server, n int) []int {
+	var codes []int
+	for i := 0; i < n; i++ {
+		code, _ := do(t, srv, "GET", AccountsPath+"/"+uuid.New().String(), "")
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func TestFaultRulePicks(t *testing.T) {
+	tests := map[string]struct {
+		rule FaultRule
+		want []int
+	}{
+		"every request": {
+			rule: FaultRule{Route: RouteFetch, Fault: ErrorFault(500)},
+			want: []int{500, 500, 500, 500},
+		},
+		"nth requests": {
+			rule: FaultRule{Route: RouteFetch, Nth: []int{2, 4}, Fault: ErrorFault(502)},
+			want: []int{404, 502, 404, 502},
+		},
+		"burst": {
+			rule: FaultRule{Route: RouteAny, Nth: Requests(1, 3), Fault: ErrorFault(503)},
+			want: []int{503, 503, 503, 404},
+		},
+		"other route": {
+			rule: FaultRule{Route: RouteCreate, Fault: ErrorFault(500)},
+			want: []int{404, 404, 404, 404},
+		},
+		"never": {
+			rule: FaultRule{Route: RouteFetch, Nth: []int{5}, Fault: ErrorFault(504)},
+			want: []int{404, 404, 404, 404},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			srv := newTestServer(t)
+			srv.SetFaults(FaultPlan{Rules: []FaultRule{tc.rule}})
+
+			assert.Equal(t, tc.want, fetchStatuses(t, srv, len(tc.want)))
+			assert.Equal(t, len(tc.want), srv.Requests(RouteFetch))
+			assert.Equal(t, len(tc.want), srv.Requests(RouteAny))
+		})
+	}
+}
+
+func TestFaultRuleProbability(t *testing.T) {
+	srv := newTestServer(t)
+	plan := FaultPlan{
+		Rules: []FaultRule{{Route: RouteFetch, Probability: 0.5, Fault: ErrorFault(500)}},
+		Seed:  42,
+	}
+
+	srv.SetFaults(plan)
+	first := fetchStatuses(t, srv, 50)
+
+	srv.SetFaults(plan)
+	second := fetchStatuses(t, srv, 50)
+
+	assert.Equal(t, first, second, "seeded picks are reproducible")
+	assert.Contains(t, first, 500)
+	assert.Contains(t, first, 404)
+}
+
+func TestFaultRuleOrder(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{
+		{Route: RouteFetch, Nth: []int{1}, Fault: ErrorFault(502)},
+		{Route: RouteFetch, Nth: []int{1, 2}, Fault: ErrorFault(503)},
+	}})
+
+	assert.Equal(t, []int{502, 503, 404}, fetchStatuses(t, srv, 3))
+
+	srv.ClearFaults()
+	assert.Equal(t, []int{404}, fetchStatuses(t, srv, 1))
+
+	srv.Reset()
+	assert.Equal(t, 0, srv.Requests(RouteAny))
+}
+
+func TestStatusFaults(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{
+		{Route: RouteFetch, Nth: []int{1}, Fault: RateLimitFault(1500 * time.Millisecond)},
+		{Route: RouteFetch, Nth: []int{2}, Fault: EmptyBodyFault(503)},
+	}})
+	url := srv.URL + AccountsPath + "/" + uuid.New().String()
+
+	resp, err := srv.Client().Get(url)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+	assert.JSONEq(t, `{"error_message": "too many requests"}`, string(body))
+
+	resp, err = srv.Client().Get(url)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Empty(t, body)
+}
+
+func TestTruncateFault(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+	code, _ := do(t, srv, "POST", AccountsPath, accountBody(id, "GB"))
+	require.Equal(t, http.StatusCreated, code)
+
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{{Route: RouteFetch, Fault: TruncateFault()}}})
+
+	resp, err := srv.Client().Get(srv.URL + AccountsPath + "/" + id)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, body)
+	assert.Equal(t, byte('{'), body[0])
+	assert.NotEqual(t, byte('}'), body[len(body)-1])
+}
+
+func TestResetFault(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{{Route: RouteList, Nth: []int{1}, Fault: ResetFault()}}})
+
+	_, err := srv.Client().Get(srv.URL + AccountsPath)
+	assert.Error(t, err)
+
+	resp, err := srv.Client().Get(srv.URL + AccountsPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, srv.Requests(RouteList))
+}
+
+func TestResetFaultHTTP2(t *testing.T) {
+	srv := NewUnstartedServer()
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{{Route: RouteList, Nth: []int{1}, Fault: ResetFault()}}})
+
+	_, err := srv.Client().Get(srv.URL + AccountsPath)
+	assert.Error(t, err)
+
+	resp, err := srv.Client().Get(srv.URL + AccountsPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
+
+func TestDelayFault(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{{Route: RouteList, Fault: DelayFault(time.Minute)}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+AccountsPath, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = srv.Client().Do(req)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(10*time.Second))
+
+	srv.SetFaults(FaultPlan{Rules: []FaultRule{{Route: RouteList, Fault: DelayFault(10 * time.Millisecond)}}})
+	resp, err := srv.Client().Get(srv.URL + AccountsPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
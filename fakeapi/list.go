@@ -0,0 +1,143 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// filterAttributes are the attributes listings can be filtered by through
+// filter[<attribute>] query parameters, several values being comma separated
+var filterAttributes = map[string]bool{
+	"bank_id":        true,
+	"bank_id_code":   true,
+	"account_number": true,
+	"iban":           true,
+	"customer_id":    true,
+	"country":        true,
+}
+
+// list answers a page of accounts, in creation order. page[number] starts
+// at 0 and also accepts first and last, like the platform
+func (s *Server) list(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+
+	size := DefaultPageSize
+	if v := q.Get("page[size]"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("page[size] must be between 1 and %d", MaxPageSize))
+			return
+		}
+		size = n
+	}
+
+	filters := map[string][]string{}
+	for k, v := range q {
+		if !strings.HasPrefix(k, "filter[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		attr := k[len("filter[") : len(k)-1]
+		if !filterAttributes[attr] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported filter: %s", k))
+			return
+		}
+		for _, values := range v {
+			filters[attr] = append(filters[attr], strings.Split(values, ",")...)
+		}
+	}
+
+	s.mu.Lock()
+	var matched []map[string]interface{}
+	for _, id := range s.order {
+		data := s.accounts[id].data
+		if matches(data, filters) {
+			matched = append(matched, copyObject(data))
+		}
+	}
+	s.mu.Unlock()
+
+	last := 0
+	if len(matched) > 0 {
+		last = (len(matched) - 1) / size
+	}
+
+	number := 0
+	switch v := q.Get("page[number]"); v {
+	case "", "first":
+	case "last":
+		number = last
+	default:
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "page[number] must be a positive number, first or last")
+			return
+		}
+		number = n
+	}
+
+	page := []map[string]interface{}{}
+	if start := number * size; start < len(matched) {
+		end := start + size
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page = matched[start:end]
+	}
+
+	linkQuery := url.Values{}
+	for k, v := range q {
+		if k != "page[number]" {
+			linkQuery[k] = v
+		}
+	}
+
+	links := map[string]string{
+		"self":  pageLink(linkQuery, strconv.Itoa(number)),
+		"first": pageLink(linkQuery, "first"),
+		"last":  pageLink(linkQuery, "last"),
+	}
+	if number < last {
+		links["next"] = pageLink(linkQuery, strconv.Itoa(number+1))
+	}
+	if number > 0 && number <= last {
+		links["prev"] = pageLink(linkQuery, strconv.Itoa(number-1))
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{"data": page, "links": links})
+	writeRaw(w, http.StatusOK, b)
+}
+
+// matches reports whether the account has, for every filtered attribute,
+// one of the filtered values
+func matches(data map[string]interface{}, filters map[string][]string) bool {
+	attr, _ := data["attributes"].(map[string]interface{})
+	for name, values := range filters {
+		got := fmt.Sprint(attr[name])
+		found := false
+		for _, v := range values {
+			if attr[name] != nil && got == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// pageLink builds a pagination link, relative to the API root like the
+// links of the platform
+func pageLink(q url.Values, number string) string {
+	q2 := url.Values{}
+	for k, v := range q {
+		q2[k] = v
+	}
+	q2.Set("page[number]", number)
+	return AccountsPath + "?" + q2.Encode()
+}
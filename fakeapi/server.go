@@ -0,0 +1,439 @@
+// Package fakeapi is an in-process fake of the Accounts API, for tests and
+// development without the external service. Accounts are kept in memory and
+// answered the way the platform answers them: JSON:API documents, pagination
+// links and {"error_message": ...} error bodies.
+//
+// The package does not depend on the client so that it can be used to test
+// any consumer of the API, and so that its answers do not follow the client's
+// assumptions:
+//
+//	srv := fakeapi.NewServer()
+//	defer srv.Close()
+//
+//	accClient, err := accounts.New(accounts.WithBaseURL(srv.URL))
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AccountsPath is the path the accounts collection is served under
+	AccountsPath = "/v1/organisation/accounts"
+
+	// DefaultPageSize is the page size of listings not asking for one
+	DefaultPageSize = 100
+
+	// MaxPageSize is the largest page size listings accept
+	MaxPageSize = 100
+
+	contentType     = "application/vnd.api+json"
+	timestampFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// Error messages of the platform, matched by some consumers
+const (
+	MsgDuplicateAccount = "Account cannot be created as it violates a duplicate constraint"
+	MsgInvalidVersion   = "invalid version"
+)
+
+// Server is a fake Accounts API listening on a local httptest.Server.
+// It is safe for concurrent use
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	accounts    map[string]*record
+	order       []string
+	idempotency map[string]*storedResponse
+	requests    map[Route]int
+	faults      *faultState
+
+	// now is swapped out by tests
+	now func() time.Time
+}
+
+// record is a stored account, kept as the JSON object it was created from so
+// that attributes unknown to the fake are returned unchanged
+type record struct {
+	data map[string]interface{}
+}
+
+// storedResponse is the response to a create request, replayed when the
+// request is sent again with the same Idempotency-Key
+type storedResponse struct {
+	body   []byte
+	status int
+	resp   []byte
+}
+
+// NewServer starts a fake server with no accounts. Close it once done
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a fake server that is not listening yet, so
+// that its httptest.Server can be configured, e.g. to serve TLS through
+// StartTLS. Start or StartTLS it before use
+func NewUnstartedServer() *Server {
+	s := &Server{
+		accounts:    map[string]*record{},
+		idempotency: map[string]*storedResponse{},
+		requests:    map[Route]int{},
+		now:         time.Now,
+	}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// Reset deletes every account, forgets the idempotency keys seen so far,
+// clears the request counts and stops injecting faults
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts = map[string]*record{}
+	s.order = nil
+	s.idempotency = map[string]*storedResponse{}
+	s.requests = map[Route]int{}
+	s.faults = nil
+}
+
+// Len returns the number of accounts stored
+func (s *Server) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.accounts)
+}
+
+// Account returns the stored JSON object of an account, ok is false when
+// there is none with this id
+func (s *Server) Account(id string) (data map[string]interface{}, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.accounts[id]
+	if !ok {
+		return nil, false
+	}
+	return copyObject(rec.data), true
+}
+
+// ServeHTTP answers the requests of the Accounts API, injecting the faults
+// of the plan set through SetFaults
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if f, ok := s.pickFault(routeOf(req)); ok && s.inject(w, req, f) {
+		return
+	}
+
+	s.route(w, req)
+}
+
+// routeOf tells which endpoint the request is for
+func routeOf(req *http.Request) Route {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+
+	switch {
+	case path == AccountsPath && req.Method == http.MethodPost:
+		return RouteCreate
+	case path == AccountsPath && req.Method == http.MethodGet:
+		return RouteList
+	case !strings.HasPrefix(path, AccountsPath+"/") || strings.Contains(path[len(AccountsPath)+1:], "/"):
+		return RouteOther
+	case req.Method == http.MethodGet:
+		return RouteFetch
+	case req.Method == http.MethodPatch:
+		return RouteUpdate
+	case req.Method == http.MethodDelete:
+		return RouteDelete
+	default:
+		return RouteOther
+	}
+}
+
+func (s *Server) route(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+
+	switch {
+	case path == AccountsPath:
+		switch req.Method {
+		case http.MethodGet:
+			s.list(w, req)
+		case http.MethodPost:
+			s.create(w, req)
+		default:
+			methodNotAllowed(w, "GET, POST")
+		}
+
+	case strings.HasPrefix(path, AccountsPath+"/") && !strings.Contains(path[len(AccountsPath)+1:], "/"):
+		id := path[len(AccountsPath)+1:]
+		if _, err := uuid.Parse(id); err != nil {
+			writeError(w, http.StatusBadRequest, "id is not a valid uuid")
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			s.fetch(w, id)
+		case http.MethodPatch:
+			s.update(w, req, id)
+		case http.MethodDelete:
+			s.delete(w, req, id)
+		default:
+			methodNotAllowed(w, "GET, PATCH, DELETE")
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", req.Method, req.URL.Path))
+	}
+}
+
+func (s *Server) create(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := req.Header.Get("Idempotency-Key")
+	if stored, ok := s.idempotency[key]; ok && key != "" {
+		if !bytes.Equal(stored.body, body) {
+			writeError(w, http.StatusUnprocessableEntity, "idempotency key already used for a different request")
+			return
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeRaw(w, stored.status, stored.resp)
+		return
+	}
+
+	status, resp := s.createLocked(body)
+	if key != "" && status < 500 {
+		s.idempotency[key] = &storedResponse{body: body, status: status, resp: resp}
+	}
+	writeRaw(w, status, resp)
+}
+
+// createLocked creates the account described by body and returns the
+// response to send. s.mu is held
+func (s *Server) createLocked(body []byte) (int, []byte) {
+	data, msg := decodeResource(body)
+	if msg != "" {
+		return http.StatusBadRequest, errorBody(msg)
+	}
+
+	var problems []string
+	if data["type"] != "accounts" {
+		problems = append(problems, "type in body should be one of [accounts]")
+	}
+	id, _ := data["id"].(string)
+	if _, err := uuid.Parse(id); err != nil {
+		problems = append(problems, "id in body must be of type uuid")
+	}
+	if orgID, _ := data["organisation_id"].(string); orgID == "" {
+		problems = append(problems, "organisation_id in body is required")
+	} else if _, err := uuid.Parse(orgID); err != nil {
+		problems = append(problems, "organisation_id in body must be of type uuid")
+	}
+	if _, ok := data["attributes"].(map[string]interface{}); !ok {
+		problems = append(problems, "attributes in body is required")
+	}
+	if len(problems) > 0 {
+		return http.StatusBadRequest, errorBody("validation failure list:\n" + strings.Join(problems, "\n"))
+	}
+
+	if _, ok := s.accounts[id]; ok {
+		return http.StatusConflict, errorBody(MsgDuplicateAccount)
+	}
+
+	now := s.now().UTC().Format(timestampFormat)
+	data["version"] = 0
+	data["created_on"] = now
+	data["modified_on"] = now
+
+	s.accounts[id] = &record{data: data}
+	s.order = append(s.order, id)
+
+	return http.StatusCreated, resourceBody(data)
+}
+
+func (s *Server) fetch(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return
+	}
+
+	writeRaw(w, http.StatusOK, resourceBody(rec.data))
+}
+
+// update merges the attributes of the request into the account, provided
+// the request names the current version of the account
+func (s *Server) update(w http.ResponseWriter, req *http.Request, id string) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	data, msg := decodeResource(body)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	version, ok := data["version"].(float64)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "version in body is required")
+		return
+	}
+	if bodyID, _ := data["id"].(string); bodyID != "" && bodyID != id {
+		writeError(w, http.StatusBadRequest, "id in body does not match the url")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return
+	}
+	if int(version) != rec.version() {
+		writeError(w, http.StatusConflict, MsgInvalidVersion)
+		return
+	}
+
+	if patch, ok := data["attributes"].(map[string]interface{}); ok {
+		attr, _ := rec.data["attributes"].(map[string]interface{})
+		if attr == nil {
+			attr = map[string]interface{}{}
+			rec.data["attributes"] = attr
+		}
+		for k, v := range patch {
+			attr[k] = v
+		}
+	}
+
+	rec.data["version"] = rec.version() + 1
+	rec.data["modified_on"] = s.now().UTC().Format(timestampFormat)
+
+	writeRaw(w, http.StatusOK, resourceBody(rec.data))
+}
+
+// delete removes the account, provided the request names its current version.
+// Like the platform, a missing account is answered with an empty 404
+func (s *Server) delete(w http.ResponseWriter, req *http.Request, id string) {
+	version, err := strconv.Atoi(req.URL.Query().Get("version"))
+	if err != nil || version < 0 {
+		writeError(w, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.accounts[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if version != rec.version() {
+		writeError(w, http.StatusConflict, MsgInvalidVersion)
+		return
+	}
+
+	delete(s.accounts, id)
+	for i, o := range s.order {
+		if o == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *record) version() int {
+	switch v := r.data["version"].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// decodeResource returns the data member of a JSON:API document, or the
+// message of the error to answer with
+func decodeResource(body []byte) (map[string]interface{}, string) {
+	var doc struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Sprintf("invalid request body: %s", err)
+	}
+	if doc.Data == nil {
+		return nil, "data in body is required"
+	}
+	return doc.Data, ""
+}
+
+func resourceBody(data map[string]interface{}) []byte {
+	id, _ := data["id"].(string)
+	b, _ := json.Marshal(map[string]interface{}{
+		"data":  data,
+		"links": map[string]string{"self": AccountsPath + "/" + id},
+	})
+	return b
+}
+
+// errorBody returns the error document of the platform, the shape
+// client.APIError is decoded from
+func errorBody(msg string) []byte {
+	b, _ := json.Marshal(map[string]string{"error_message": msg})
+	return b
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeRaw(w, status, errorBody(msg))
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func copyObject(data map[string]interface{}) map[string]interface{} {
+	b, _ := json.Marshal(data)
+	var c map[string]interface{}
+	_ = json.Unmarshal(b, &c)
+	return c
+}
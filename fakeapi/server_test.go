@@ -0,0 +1,357 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *Server {
+	srv := NewUnstartedServer()
+	srv.now = func() time.Time { return time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC) }
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, srv *Server, method, path, body string, headers ...string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(method, srv.URL+path, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	if len(b) > 0 {
+		require.NoError(t, json.Unmarshal(b, &doc), string(b))
+		assert.Equal(t, "application/vnd.api+json", resp.Header.Get("Content-Type"))
+	}
+	return resp.StatusCode, doc
+}
+
+func accountBody(id, country string) string {
+	return fmt.Sprintf(`{"data": {"type": "accounts", "id": "%s", "organisation_id": "%s",
+		"attributes": {"country": "%s", "bank_id": "400300", "name": ["John Doe"]}}}`, id, uuid.New(), country)
+}
+
+func TestCreateAndFetch(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+
+	code, doc := do(t, srv, "POST", AccountsPath, accountBody(id, "GB"))
+	require.Equal(t, http.StatusCreated, code)
+
+	data := doc["data"].(map[string]interface{})
+	assert.Equal(t, id, data["id"])
+	assert.Equal(t, float64(0), data["version"])
+	assert.Equal(t, "2021-05-25T04:29:11.898Z", data["created_on"])
+	assert.Equal(t, "2021-05-25T04:29:11.898Z", data["modified_on"])
+	assert.Equal(t, "GB", data["attributes"].(map[string]interface{})["country"])
+	assert.Equal(t, AccountsPath+"/"+id, doc["links"].(map[string]interface{})["self"])
+
+	code, fetched := do(t, srv, "GET", AccountsPath+"/"+id, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, doc["data"], fetched["data"])
+	assert.Equal(t, 1, srv.Len())
+}
+
+func TestCreateErrors(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+
+	code, _ := do(t, srv, "POST", AccountsPath, accountBody(id, "GB"))
+	require.Equal(t, http.StatusCreated, code)
+
+	tests := map[string]struct {
+		body string
+		code int
+		msg  string
+	}{
+		"duplicate id":   {body: accountBody(id, "FR"), code: http.StatusConflict, msg: MsgDuplicateAccount},
+		"malformed json": {body: `{"data": `, code: http.StatusBadRequest},
+		"missing data":   {body: `{}`, code: http.StatusBadRequest, msg: "data in body is required"},
+		"invalid resource": {
+			body: `{"data": {"type": "account", "id": "1"}}`,
+			code: http.StatusBadRequest,
+			msg: "validation failure list:\ntype in body should be one of [accounts]\nid in body must be of type uuid\n" +
+				"organisation_id in body is required\nattributes in body is required",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			code, doc := do(t, srv, "POST", AccountsPath, tc.body)
+
+			assert.Equal(t, tc.code, code)
+			assert.NotEmpty(t, doc["error_message"])
+			if tc.msg != "" {
+				assert.Equal(t, tc.msg, doc["error_message"])
+			}
+		})
+	}
+
+	assert.Equal(t, 1, srv.Len())
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	srv := newTestServer(t)
+	body := accountBody(uuid.New().String(), "GB")
+
+	code, first := do(t, srv, "POST", AccountsPath, body, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusCreated, code)
+
+	code, replayed := do(t, srv, "POST", AccountsPath, body, "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusCreated, code, "replayed rather than rejected as a duplicate")
+	assert.Equal(t, first, replayed)
+
+	code, _ = do(t, srv, "POST", AccountsPath, body, "Idempotency-Key", "key-2")
+	assert.Equal(t, http.StatusConflict, code, "a new key is a new request")
+
+	code, doc := do(t, srv, "POST", AccountsPath, accountBody(uuid.New().String(), "GB"), "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusUnprocessableEntity, code, "key reused for another request")
+	assert.NotEmpty(t, doc["error_message"])
+
+	assert.Equal(t, 1, srv.Len())
+}
+
+func TestFetchErrors(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+
+	code, doc := do(t, srv, "GET", AccountsPath+"/"+id, "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, fmt.Sprintf("record %s does not exist", id), doc["error_message"])
+
+	code, doc = do(t, srv, "GET", AccountsPath+"/not-a-uuid", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "id is not a valid uuid", doc["error_message"])
+}
+
+func TestDelete(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+	code, _ := do(t, srv, "POST", AccountsPath, accountBody(id, "GB"))
+	require.Equal(t, http.StatusCreated, code)
+
+	code, doc := do(t, srv, "DELETE", AccountsPath+"/"+id+"?version=1", "")
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, MsgInvalidVersion, doc["error_message"])
+
+	code, _ = do(t, srv, "DELETE", AccountsPath+"/"+id, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, doc = do(t, srv, "DELETE", AccountsPath+"/"+id+"?version=0", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Nil(t, doc)
+	assert.Equal(t, 0, srv.Len())
+
+	code, doc = do(t, srv, "DELETE", AccountsPath+"/"+id+"?version=0", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Nil(t, doc, "missing accounts are answered with an empty body")
+}
+
+func TestUpdate(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+	code, _ := do(t, srv, "POST", AccountsPath, accountBody(id, "GB"))
+	require.Equal(t, http.StatusCreated, code)
+
+	srv.now = func() time.Time { return time.Date(2021, 5, 26, 0, 0, 0, 0, time.UTC) }
+
+	patch := fmt.Sprintf(`{"data": {"id": "%s", "version": 0, "attributes": {"bank_id": "400301", "status": "confirmed"}}}`, id)
+	code, doc := do(t, srv, "PATCH", AccountsPath+"/"+id, patch)
+	require.Equal(t, http.StatusOK, code)
+
+	data := doc["data"].(map[string]interface{})
+	attr := data["attributes"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["version"])
+	assert.Equal(t, "2021-05-25T04:29:11.898Z", data["created_on"])
+	assert.Equal(t, "2021-05-26T00:00:00.000Z", data["modified_on"])
+	assert.Equal(t, "400301", attr["bank_id"])
+	assert.Equal(t, "confirmed", attr["status"])
+	assert.Equal(t, "GB", attr["country"], "attributes left out are kept")
+
+	code, doc = do(t, srv, "PATCH", AccountsPath+"/"+id, patch)
+	assert.Equal(t, http.StatusConflict, code, "stale version")
+	assert.Equal(t, MsgInvalidVersion, doc["error_message"])
+
+	missing := uuid.New().String()
+	code, _ = do(t, srv, "PATCH", AccountsPath+"/"+missing, fmt.Sprintf(`{"data": {"version": 0}}`))
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do(t, srv, "PATCH", AccountsPath+"/"+id, `{"data": {"attributes": {}}}`)
+	assert.Equal(t, http.StatusBadRequest, code, "missing version")
+}
+
+func TestList(t *testing.T) {
+	srv := newTestServer(t)
+
+	var ids []string
+	for i, country := range []string{"GB", "FR", "GB", "DE", "GB"} {
+		id := fmt.Sprintf("00000000-0000-0000-0000-00000000000%d", i)
+		ids = append(ids, id)
+		code, _ := do(t, srv, "POST", AccountsPath, accountBody(id, country))
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	pageIDs := func(doc map[string]interface{}) []string {
+		var got []string
+		for _, d := range doc["data"].([]interface{}) {
+			got = append(got, d.(map[string]interface{})["id"].(string))
+		}
+		return got
+	}
+
+	tests := map[string]struct {
+		query string
+		ids   []string
+		links map[string]string
+	}{
+		"default page": {
+			ids: ids,
+			links: map[string]string{
+				"self":  AccountsPath + "?page%5Bnumber%5D=0",
+				"first": AccountsPath + "?page%5Bnumber%5D=first",
+				"last":  AccountsPath + "?page%5Bnumber%5D=last",
+			},
+		},
+		"first page": {
+			query: "?page[size]=2",
+			ids:   ids[:2],
+			links: map[string]string{
+				"self":  AccountsPath + "?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+				"first": AccountsPath + "?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+				"last":  AccountsPath + "?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+				"next":  AccountsPath + "?page%5Bnumber%5D=1&page%5Bsize%5D=2",
+			},
+		},
+		"middle page": {
+			query: "?page[number]=1&page[size]=2",
+			ids:   ids[2:4],
+			links: map[string]string{
+				"self":  AccountsPath + "?page%5Bnumber%5D=1&page%5Bsize%5D=2",
+				"first": AccountsPath + "?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+				"last":  AccountsPath + "?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+				"next":  AccountsPath + "?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+				"prev":  AccountsPath + "?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+			},
+		},
+		"last page": {
+			query: "?page[number]=last&page[size]=2",
+			ids:   ids[4:],
+			links: map[string]string{
+				"self":  AccountsPath + "?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+				"first": AccountsPath + "?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+				"last":  AccountsPath + "?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+				"prev":  AccountsPath + "?page%5Bnumber%5D=1&page%5Bsize%5D=2",
+			},
+		},
+		"past the end": {
+			query: "?page[number]=7&page[size]=2",
+			links: map[string]string{
+				"self":  AccountsPath + "?page%5Bnumber%5D=7&page%5Bsize%5D=2",
+				"first": AccountsPath + "?page%5Bnumber%5D=first&page%5Bsize%5D=2",
+				"last":  AccountsPath + "?page%5Bnumber%5D=last&page%5Bsize%5D=2",
+			},
+		},
+		"filtered": {
+			query: "?filter[country]=GB&page[size]=2",
+			ids:   []string{ids[0], ids[2]},
+			links: map[string]string{
+				"self":  AccountsPath + "?filter%5Bcountry%5D=GB&page%5Bnumber%5D=0&page%5Bsize%5D=2",
+				"first": AccountsPath + "?filter%5Bcountry%5D=GB&page%5Bnumber%5D=first&page%5Bsize%5D=2",
+				"last":  AccountsPath + "?filter%5Bcountry%5D=GB&page%5Bnumber%5D=last&page%5Bsize%5D=2",
+				"next":  AccountsPath + "?filter%5Bcountry%5D=GB&page%5Bnumber%5D=1&page%5Bsize%5D=2",
+			},
+		},
+		"filtered on several values": {
+			query: "?filter[country]=FR,DE&filter[bank_id]=400300",
+			ids:   []string{ids[1], ids[3]},
+			links: map[string]string{
+				"self":  AccountsPath + "?filter%5Bbank_id%5D=400300&filter%5Bcountry%5D=FR%2CDE&page%5Bnumber%5D=0",
+				"first": AccountsPath + "?filter%5Bbank_id%5D=400300&filter%5Bcountry%5D=FR%2CDE&page%5Bnumber%5D=first",
+				"last":  AccountsPath + "?filter%5Bbank_id%5D=400300&filter%5Bcountry%5D=FR%2CDE&page%5Bnumber%5D=last",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			code, doc := do(t, srv, "GET", AccountsPath+tc.query, "")
+			require.Equal(t, http.StatusOK, code)
+
+			assert.Equal(t, tc.ids, pageIDs(doc))
+
+			links := map[string]string{}
+			for k, v := range doc["links"].(map[string]interface{}) {
+				links[k] = v.(string)
+			}
+			assert.Equal(t, tc.links, links)
+		})
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	srv := newTestServer(t)
+
+	for _, query := range []string{"?page[size]=0", "?page[size]=101", "?page[number]=-1", "?page[number]=next", "?filter[name]=John"} {
+		code, doc := do(t, srv, "GET", AccountsPath+query, "")
+		assert.Equal(t, http.StatusBadRequest, code, query)
+		assert.NotEmpty(t, doc["error_message"], query)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	srv := newTestServer(t)
+
+	code, doc := do(t, srv, "PUT", AccountsPath, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	assert.NotEmpty(t, doc["error_message"])
+
+	code, _ = do(t, srv, "POST", AccountsPath+"/"+uuid.New().String(), "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, _ = do(t, srv, "GET", "/v1/organisation/other", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do(t, srv, "GET", AccountsPath+"/"+uuid.New().String()+"/nested", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestReset(t *testing.T) {
+	srv := newTestServer(t)
+	id := uuid.New().String()
+	body := accountBody(id, "GB")
+
+	code, _ := do(t, srv, "POST", AccountsPath, body, "Idempotency-Key", "key-1")
+	require.Equal(t, http.StatusCreated, code)
+
+	data, ok := srv.Account(id)
+	require.True(t, ok)
+	assert.Equal(t, id, data["id"])
+
+	srv.Reset()
+	assert.Equal(t, 0, srv.Len())
+	_, ok = srv.Account(id)
+	assert.False(t, ok)
+
+	code, _ = do(t, srv, "POST", AccountsPath, accountBody(uuid.New().String(), "GB"), "Idempotency-Key", "key-1")
+	assert.Equal(t, http.StatusCreated, code, "idempotency keys are forgotten")
+}
@@ -0,0 +1,60 @@
+package iban
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidBIC is returned for malformed Business Identifier Codes
+var ErrInvalidBIC = errors.New("invalid bic")
+
+// BIC is a parsed Business Identifier Code, also known as SWIFT code
+type BIC struct {
+	// Institution is the 4 letter code of the bank
+	Institution string
+
+	// Country is the ISO 3166-1 alpha-2 code of the country of the bank
+	Country string
+
+	// Location is the 2 character location code
+	Location string
+
+	// Branch is the 3 character branch code, empty for 8 character BICs
+	Branch string
+}
+
+// ParseBIC parses an 8 or 11 character BIC. The input is normalised first.
+// Errors wrap ErrInvalidBIC
+func ParseBIC(s string) (*BIC, error) {
+	s = Normalize(s)
+	if len(s) != 8 && len(s) != 11 {
+		return nil, fmt.Errorf("bic %q: %w: expected 8 or 11 characters, got %d", s, ErrInvalidBIC, len(s))
+	}
+
+	for i := 0; i < len(s); i++ {
+		kind := byte('c')
+		if i < 6 {
+			kind = 'a'
+		}
+		if !isKind(s[i], kind) {
+			return nil, fmt.Errorf("bic %q: %w: unexpected character at position %d", s, ErrInvalidBIC, i+1)
+		}
+	}
+
+	bic := &BIC{Institution: s[:4], Country: s[4:6], Location: s[6:8]}
+	if len(s) == 11 {
+		bic.Branch = s[8:]
+	}
+	return bic, nil
+}
+
+// ValidateBIC reports why s is not a valid BIC, nil when it is
+func ValidateBIC(s string) error {
+	_, err := ParseBIC(s)
+	return err
+}
+
+// String returns the BIC, 8 or 11 characters long
+func (b *BIC) String() string {
+	return b.Institution + b.Country + b.Location + b.Branch
+}
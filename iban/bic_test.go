@@ -0,0 +1,44 @@
+package iban
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBIC(t *testing.T) {
+	tests := map[string]struct {
+		bic  string
+		want *BIC
+	}{
+		"8 characters":     {bic: "NWBKGB22", want: &BIC{Institution: "NWBK", Country: "GB", Location: "22"}},
+		"11 characters":    {bic: "DEUTDEFF500", want: &BIC{Institution: "DEUT", Country: "DE", Location: "FF", Branch: "500"}},
+		"normalised":       {bic: " nwbk gb22 xxx", want: &BIC{Institution: "NWBK", Country: "GB", Location: "22", Branch: "XXX"}},
+		"too short":        {bic: "NWBKGB2"},
+		"too long":         {bic: "NWBKGB22XXXX"},
+		"9 characters":     {bic: "NWBKGB22X"},
+		"digit in bank":    {bic: "NWB1GB22"},
+		"digit in country": {bic: "NWBKG122"},
+		"punctuation":      {bic: "NWBKGB2-"},
+		"empty":            {bic: ""},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseBIC(tc.bic)
+
+			if tc.want == nil {
+				assert.ErrorIs(t, err, ErrInvalidBIC)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, Normalize(tc.bic), got.String())
+			assert.NoError(t, ValidateBIC(tc.bic))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Package iban parses and validates International Bank Account Numbers
+// (ISO 13616) and Business Identifier Codes (ISO 9362)
+package iban
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrUnsupportedCountry is returned for IBANs of countries missing from
+	// the registry, including countries not using IBANs at all
+	ErrUnsupportedCountry = errors.New("unsupported country")
+
+	// ErrInvalidLength is returned when an IBAN is not as long as its
+	// country requires
+	ErrInvalidLength = errors.New("invalid length")
+
+	// ErrInvalidStructure is returned when an IBAN has characters its
+	// country does not allow at their position
+	ErrInvalidStructure = errors.New("invalid structure")
+
+	// ErrInvalidCheckDigits is returned when the mod-97 check fails
+	ErrInvalidCheckDigits = errors.New("invalid check digits")
+)
+
+// IBAN is a parsed International Bank Account Number
+type IBAN struct {
+	// Country is the ISO 3166-1 alpha-2 code the IBAN starts with
+	Country string
+
+	// CheckDigits are the two digits following the country code
+	CheckDigits string
+
+	// BBAN is the national Basic Bank Account Number
+	BBAN string
+
+	spec spec
+}
+
+// Normalize returns s without whitespace and upper-cased, the electronic
+// format IBANs are parsed from
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// Parse parses an IBAN given in its electronic or print format. Its length
+// and structure are checked against the rules of its country as well as its
+// check digits. Errors wrap ErrUnsupportedCountry, ErrInvalidLength,
+// ErrInvalidStructure or ErrInvalidCheckDigits
+func Parse(s string) (*IBAN, error) {
+	s = Normalize(s)
+	if len(s) < 4 {
+		return nil, fmt.Errorf("iban %q: %w", s, ErrInvalidLength)
+	}
+
+	country, check, bban := s[:2], s[2:4], s[4:]
+	sp, ok := registry[country]
+	if !ok {
+		return nil, fmt.Errorf("iban %q: %w: %q", s, ErrUnsupportedCountry, country)
+	}
+	if len(s) != sp.length {
+		return nil, fmt.Errorf("iban %q: %w: expected %d characters for %s, got %d", s, ErrInvalidLength, sp.length, country, len(s))
+	}
+	if !isKind(check[0], 'n') || !isKind(check[1], 'n') || !sp.matches(bban) {
+		return nil, fmt.Errorf("iban %q: %w for %s", s, ErrInvalidStructure, country)
+	}
+	if Mod97(bban+country+check) != 1 {
+		return nil, fmt.Errorf("iban %q: %w", s, ErrInvalidCheckDigits)
+	}
+
+	return &IBAN{Country: country, CheckDigits: check, BBAN: bban, spec: sp}, nil
+}
+
+// Validate reports why s is not a valid IBAN, nil when it is
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// New builds the IBAN of a national account number, computing its check
+// digits. bban must follow the structure of the country
+func New(country, bban string) (*IBAN, error) {
+	country, bban = Normalize(country), Normalize(bban)
+
+	check, err := CheckDigits(country, bban)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(country + check + bban)
+}
+
+// CheckDigits computes the two check digits of the IBAN of a national
+// account number
+func CheckDigits(country, bban string) (string, error) {
+	country, bban = Normalize(country), Normalize(bban)
+
+	sp, ok := registry[country]
+	if !ok {
+		return "", fmt.Errorf("bban %q: %w: %q", bban, ErrUnsupportedCountry, country)
+	}
+	if len(bban) != sp.length-4 {
+		return "", fmt.Errorf("bban %q: %w: expected %d characters for %s, got %d", bban, ErrInvalidLength, sp.length-4, country, len(bban))
+	}
+	if !sp.matches(bban) {
+		return "", fmt.Errorf("bban %q: %w for %s", bban, ErrInvalidStructure, country)
+	}
+
+	return Mod97CheckDigits(bban + country), nil
+}
+
+// String returns the IBAN in its electronic format, without spaces
+func (i *IBAN) String() string {
+	return i.Country + i.CheckDigits + i.BBAN
+}
+
+// Format returns the IBAN in its print format, in groups of four characters
+func (i *IBAN) Format() string {
+	return Format(i.String())
+}
+
+// BankCode returns the national code of the bank holding the account
+func (i *IBAN) BankCode() string {
+	return i.spec.bank.of(i.BBAN)
+}
+
+// BranchCode returns the national code of the branch holding the account.
+// Empty for countries whose IBANs carry no branch code
+func (i *IBAN) BranchCode() string {
+	return i.spec.branch.of(i.BBAN)
+}
+
+// AccountNumber returns the account number, without any national check digits
+func (i *IBAN) AccountNumber() string {
+	return i.spec.account.of(i.BBAN)
+}
+
+// Format normalises s and splits it in groups of four characters, the print
+// format of IBANs. s is not validated
+func Format(s string) string {
+	s = Normalize(s)
+
+	var b strings.Builder
+	for i := 0; i < len(s); i += 4 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		end := i + 4
+		if end > len(s) {
+			end = len(s)
+		}
+		b.WriteString(s[i:end])
+	}
+	return b.String()
+}
+
+// Mod97CheckDigits computes the two ISO 7064 MOD 97-10 check digits to
+// append to s, the scheme of IBANs and of some national account numbers.
+// s holds digits and upper case letters only
+func Mod97CheckDigits(s string) string {
+	return fmt.Sprintf("%02d", 98-Mod97(s+"00"))
+}
+
+// Mod97 computes the ISO 7064 MOD 97-10 remainder of s, letters counting
+// as two digit numbers from A=10 to Z=35. s holds digits and upper case
+// letters only
+func Mod97(s string) int {
+	r := 0
+	for _, c := range []byte(s) {
+		if c >= 'A' && c <= 'Z' {
+			r = (r*100 + int(c-'A') + 10) % 97
+		} else {
+			r = (r*10 + int(c-'0')) % 97
+		}
+	}
+	return r
+}
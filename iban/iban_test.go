@@ -0,0 +1,179 @@
+package iban
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Examples published in the SWIFT IBAN registry
+var registryExamples = map[string]string{
+	"AD": "AD1200012030200359100100",
+	"AE": "AE070331234567890123456",
+	"AT": "AT611904300234573201",
+	"BE": "BE68539007547034",
+	"BG": "BG80BNBG96611020345678",
+	"CH": "CH9300762011623852957",
+	"CY": "CY17002001280000001200527600",
+	"CZ": "CZ6508000000192000145399",
+	"DE": "DE89370400440532013000",
+	"DK": "DK5000400440116243",
+	"EE": "EE382200221020145685",
+	"ES": "ES9121000418450200051332",
+	"FI": "FI2112345600000785",
+	"FR": "FR1420041010050500013M02606",
+	"GB": "GB29NWBK60161331926819",
+	"GI": "GI75NWBK000000007099453",
+	"GR": "GR1601101250000000012300695",
+	"HR": "HR1210010051863000160",
+	"HU": "HU42117730161111101800000000",
+	"IE": "IE29AIBK93115212345678",
+	"IT": "IT60X0542811101000000123456",
+	"LI": "LI21088100002324013AA",
+	"LT": "LT121000011101001000",
+	"LU": "LU280019400644750000",
+	"LV": "LV80BANK0000435195001",
+	"MC": "MC5811222000010123456789030",
+	"MT": "MT84MALT011000012345MTLCAST001S",
+	"NL": "NL91ABNA0417164300",
+	"NO": "NO9386011117947",
+	"PL": "PL61109010140000071219812874",
+	"PT": "PT50000201231234567890154",
+	"RO": "RO49AAAA1B31007593840000",
+	"SA": "SA0380000000608010167519",
+	"SE": "SE4550000000058398257466",
+	"SI": "SI56263300012039086",
+	"SK": "SK3112000000198742637541",
+	"SM": "SM86U0322509800000000270100",
+	"TR": "TR330006100519786457841326",
+}
+
+func TestParseRegistryExamples(t *testing.T) {
+	for country := range registry {
+		assert.Contains(t, registryExamples, country, "every registered country has an example")
+	}
+
+	for country, example := range registryExamples {
+		country, example := country, example
+		t.Run(country, func(t *testing.T) {
+			got, err := Parse(example)
+			require.NoError(t, err)
+
+			assert.Equal(t, country, got.Country)
+			assert.Equal(t, example, got.String())
+
+			printed, err := Parse(got.Format())
+			require.NoError(t, err, "print format parses back")
+			assert.Equal(t, got, printed)
+
+			rebuilt, err := New(country, got.BBAN)
+			require.NoError(t, err)
+			assert.Equal(t, got.CheckDigits, rebuilt.CheckDigits)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]struct {
+		iban string
+		err  error
+	}{
+		"empty":                  {iban: "", err: ErrInvalidLength},
+		"too short":              {iban: "GB2", err: ErrInvalidLength},
+		"unsupported country":    {iban: "US64SVBKUS6S3300958879", err: ErrUnsupportedCountry},
+		"not a country":          {iban: "1234", err: ErrUnsupportedCountry},
+		"one character short":    {iban: "GB29NWBK6016133192681", err: ErrInvalidLength},
+		"one character too many": {iban: "GB29NWBK601613319268190", err: ErrInvalidLength},
+		"digits in bank code":    {iban: "GB29NWB060161331926819", err: ErrInvalidStructure},
+		"letters in account":     {iban: "GB29NWBK6016133192681A", err: ErrInvalidStructure},
+		"letters as check":       {iban: "GBXXNWBK60161331926819", err: ErrInvalidStructure},
+		"wrong check digits":     {iban: "GB28NWBK60161331926819", err: ErrInvalidCheckDigits},
+		"swapped digits":         {iban: "GB29NWBK60161331926891", err: ErrInvalidCheckDigits},
+		"punctuation":            {iban: "GB29-NWBK-6016-1331-9268-19", err: ErrInvalidLength},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.iban)
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Nil(t, got)
+			assert.ErrorIs(t, Validate(tc.iban), tc.err)
+		})
+	}
+}
+
+func TestNormalizeAndFormat(t *testing.T) {
+	assert.Equal(t, "GB29NWBK60161331926819", Normalize(" gb29 nwbk 6016\t1331 9268 19\n"))
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", Format("gb29nwbk60161331926819"))
+	assert.Equal(t, "NO93 8601 1117 947", Format("NO9386011117947"))
+	assert.Equal(t, "", Format(""))
+
+	got, err := Parse("gb29 nwbk 6016 1331 9268 19")
+	require.NoError(t, err)
+	assert.Equal(t, "GB29NWBK60161331926819", got.String())
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", got.Format())
+}
+
+func TestFields(t *testing.T) {
+	tests := map[string]struct {
+		bank, branch, account string
+	}{
+		"GB29NWBK60161331926819":      {bank: "NWBK", branch: "601613", account: "31926819"},
+		"DE89370400440532013000":      {bank: "37040044", account: "0532013000"},
+		"FR1420041010050500013M02606": {bank: "20041", branch: "01005", account: "0500013M026"},
+		"IT60X0542811101000000123456": {bank: "05428", branch: "11101", account: "000000123456"},
+		"ES9121000418450200051332":    {bank: "2100", branch: "0418", account: "0200051332"},
+		"NL91ABNA0417164300":          {bank: "ABNA", account: "0417164300"},
+		"BE68539007547034":            {bank: "539", account: "0075470"},
+		"PT50000201231234567890154":   {bank: "0002", branch: "0123", account: "12345678901"},
+	}
+
+	for s, tc := range tests {
+		s, tc := s, tc
+		t.Run(s, func(t *testing.T) {
+			got, err := Parse(s)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.bank, got.BankCode())
+			assert.Equal(t, tc.branch, got.BranchCode())
+			assert.Equal(t, tc.account, got.AccountNumber())
+		})
+	}
+}
+
+func TestCheckDigits(t *testing.T) {
+	check, err := CheckDigits("GB", "NWBK60161331926819")
+	require.NoError(t, err)
+	assert.Equal(t, "29", check)
+
+	check, err = CheckDigits("no", "8601 1117 947")
+	require.NoError(t, err)
+	assert.Equal(t, "93", check)
+
+	_, err = CheckDigits("US", "123456789")
+	assert.ErrorIs(t, err, ErrUnsupportedCountry)
+
+	_, err = CheckDigits("GB", "NWBK6016133192681")
+	assert.ErrorIs(t, err, ErrInvalidLength)
+
+	_, err = CheckDigits("GB", "1234601613319268AB")
+	assert.ErrorIs(t, err, ErrInvalidStructure)
+}
+
+func TestMod97(t *testing.T) {
+	// National check digits of the PT and BE registry examples
+	assert.Equal(t, "54", Mod97CheckDigits("0002012312345678901"))
+	assert.Equal(t, 34, Mod97("5390075470"))
+
+	assert.Equal(t, 1, Mod97("NWBK60161331926819GB29"))
+	assert.Equal(t, "29", Mod97CheckDigits("NWBK60161331926819GB"))
+}
+
+func TestSupported(t *testing.T) {
+	assert.True(t, Supported("GB"))
+	assert.False(t, Supported("US"))
+	assert.False(t, Supported("gb"))
+}
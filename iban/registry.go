@@ -0,0 +1,157 @@
+package iban
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// segment is a run of characters of the same kind in a BBAN structure,
+// written in the SWIFT registry notation as e.g. 4!a or 10!n
+type segment struct {
+	length int
+	kind   byte // 'n' digits, 'a' upper case letters, 'c' digits and upper case letters
+}
+
+// span locates a field inside the BBAN, end excluded. Zero when the
+// country's IBANs do not carry the field
+type span struct {
+	start, end int
+}
+
+// spec describes the IBANs of a country
+type spec struct {
+	length    int
+	structure []segment
+	bank      span
+	branch    span
+	account   span
+}
+
+// registry holds the IBAN formats of the countries this package knows,
+// as published in the SWIFT IBAN registry
+var registry = map[string]spec{
+	"AD": newSpec(24, "4!n4!n12!c", span{0, 4}, span{4, 8}, span{8, 20}),
+	"AE": newSpec(23, "3!n16!n", span{0, 3}, span{}, span{3, 19}),
+	"AT": newSpec(20, "5!n11!n", span{0, 5}, span{}, span{5, 16}),
+	"BE": newSpec(16, "3!n7!n2!n", span{0, 3}, span{}, span{3, 10}),
+	"BG": newSpec(22, "4!a4!n2!n8!c", span{0, 4}, span{4, 8}, span{10, 18}),
+	"CH": newSpec(21, "5!n12!c", span{0, 5}, span{}, span{5, 17}),
+	"CY": newSpec(28, "3!n5!n16!c", span{0, 3}, span{3, 8}, span{8, 24}),
+	"CZ": newSpec(24, "4!n6!n10!n", span{0, 4}, span{}, span{4, 20}),
+	"DE": newSpec(22, "8!n10!n", span{0, 8}, span{}, span{8, 18}),
+	"DK": newSpec(18, "4!n9!n1!n", span{0, 4}, span{}, span{4, 14}),
+	"EE": newSpec(20, "2!n14!n", span{0, 2}, span{}, span{2, 16}),
+	"ES": newSpec(24, "4!n4!n1!n1!n10!n", span{0, 4}, span{4, 8}, span{10, 20}),
+	"FI": newSpec(18, "3!n11!n", span{0, 3}, span{}, span{3, 14}),
+	"FR": newSpec(27, "5!n5!n11!c2!n", span{0, 5}, span{5, 10}, span{10, 21}),
+	"GB": newSpec(22, "4!a6!n8!n", span{0, 4}, span{4, 10}, span{10, 18}),
+	"GI": newSpec(23, "4!a15!c", span{0, 4}, span{}, span{4, 19}),
+	"GR": newSpec(27, "3!n4!n16!c", span{0, 3}, span{3, 7}, span{7, 23}),
+	"HR": newSpec(21, "7!n10!n", span{0, 7}, span{}, span{7, 17}),
+	"HU": newSpec(28, "3!n4!n1!n15!n1!n", span{0, 3}, span{3, 7}, span{8, 24}),
+	"IE": newSpec(22, "4!a6!n8!n", span{0, 4}, span{4, 10}, span{10, 18}),
+	"IT": newSpec(27, "1!a5!n5!n12!c", span{1, 6}, span{6, 11}, span{11, 23}),
+	"LI": newSpec(21, "5!n12!c", span{0, 5}, span{}, span{5, 17}),
+	"LT": newSpec(20, "5!n11!n", span{0, 5}, span{}, span{5, 16}),
+	"LU": newSpec(20, "3!n13!c", span{0, 3}, span{}, span{3, 16}),
+	"LV": newSpec(21, "4!a13!c", span{0, 4}, span{}, span{4, 17}),
+	"MC": newSpec(27, "5!n5!n11!c2!n", span{0, 5}, span{5, 10}, span{10, 21}),
+	"MT": newSpec(31, "4!a5!n18!c", span{0, 4}, span{4, 9}, span{9, 27}),
+	"NL": newSpec(18, "4!a10!n", span{0, 4}, span{}, span{4, 14}),
+	"NO": newSpec(15, "4!n6!n1!n", span{0, 4}, span{}, span{4, 10}),
+	"PL": newSpec(28, "8!n16!n", span{0, 8}, span{}, span{8, 24}),
+	"PT": newSpec(25, "4!n4!n11!n2!n", span{0, 4}, span{4, 8}, span{8, 19}),
+	"RO": newSpec(24, "4!a16!c", span{0, 4}, span{}, span{4, 20}),
+	"SA": newSpec(24, "2!n18!c", span{0, 2}, span{}, span{2, 20}),
+	"SE": newSpec(24, "3!n16!n1!n", span{0, 3}, span{}, span{3, 19}),
+	"SI": newSpec(19, "5!n8!n2!n", span{0, 5}, span{}, span{5, 13}),
+	"SK": newSpec(24, "4!n6!n10!n", span{0, 4}, span{}, span{4, 20}),
+	"SM": newSpec(27, "1!a5!n5!n12!c", span{1, 6}, span{6, 11}, span{11, 23}),
+	"TR": newSpec(26, "5!n1!n16!c", span{0, 5}, span{}, span{6, 22}),
+}
+
+// newSpec builds the spec of a country from the BBAN structure published in
+// the registry. It panics on registry mistakes, caught by the tests
+func newSpec(length int, structure string, bank, branch, account span) spec {
+	segments, err := parseStructure(structure)
+	if err != nil {
+		panic(err)
+	}
+
+	bbanLength := 0
+	for _, s := range segments {
+		bbanLength += s.length
+	}
+	if bbanLength != length-4 {
+		panic(fmt.Sprintf("iban: structure %s does not add up to length %d", structure, length))
+	}
+
+	return spec{length: length, structure: segments, bank: bank, branch: branch, account: account}
+}
+
+func parseStructure(structure string) ([]segment, error) {
+	var segments []segment
+	for i := 0; i < len(structure); {
+		j := i
+		for j < len(structure) && structure[j] >= '0' && structure[j] <= '9' {
+			j++
+		}
+		if j == i || j+1 >= len(structure) || structure[j] != '!' {
+			return nil, fmt.Errorf("iban: malformed structure %q", structure)
+		}
+
+		n, _ := strconv.Atoi(structure[i:j])
+		kind := structure[j+1]
+		if kind != 'n' && kind != 'a' && kind != 'c' {
+			return nil, fmt.Errorf("iban: malformed structure %q", structure)
+		}
+
+		segments = append(segments, segment{length: n, kind: kind})
+		i = j + 2
+	}
+	return segments, nil
+}
+
+// matches reports whether bban follows the structure
+func (s spec) matches(bban string) bool {
+	if len(bban) != s.length-4 {
+		return false
+	}
+
+	i := 0
+	for _, seg := range s.structure {
+		for _, c := range []byte(bban[i : i+seg.length]) {
+			if !isKind(c, seg.kind) {
+				return false
+			}
+		}
+		i += seg.length
+	}
+	return true
+}
+
+func isKind(c byte, kind byte) bool {
+	digit := c >= '0' && c <= '9'
+	letter := c >= 'A' && c <= 'Z'
+	switch kind {
+	case 'n':
+		return digit
+	case 'a':
+		return letter
+	default:
+		return digit || letter
+	}
+}
+
+func (s span) of(bban string) string {
+	if s.end == 0 {
+		return ""
+	}
+	return bban[s.start:s.end]
+}
+
+// Supported reports whether the IBAN format of the country is known
+func Supported(country string) bool {
+	_, ok := registry[country]
+	return ok
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrWaitExceedsDeadline is returned by RateLimiter.Wait when the context's
+// deadline is too close to ever get a token. Unlike context.DeadlineExceeded
+// it is not a timeout, so that requests it fails are not retried
+var ErrWaitExceedsDeadline = errors.New("client: rate limiter wait exceeds context deadline")
+
+// RateLimiter is a token bucket limiting how many requests are sent per
+// second. The bucket holds up to burst tokens and is refilled at a steady
+// rate. A single RateLimiter is safe for concurrent use and is meant to be
+// shared by all the clients that draw from the same quota
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// now is swapped out by tests
+	now func() time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second on
+// average, with bursts of up to burst requests. The bucket starts full
+func NewRateLimiter(rps float64, burst int) (*RateLimiter, error) {
+	if rps <= 0 {
+		return nil, fmt.Errorf("client.NewRateLimiter: rate must be positive: %v", rps)
+	}
+	if burst < 1 {
+		return nil, fmt.Errorf("client.NewRateLimiter: burst must be positive: %d", burst)
+	}
+
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}, nil
+}
+
+// Allow takes a token if one is available right away, without waiting
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(l.now())
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Wait blocks until a token is available or ctx is done. A token is reserved
+// up front so that waiters are served in order. When ctx is cancelled, or its
+// deadline is too close to ever get the token, the reservation is returned
+// to the bucket along with ctx's error, or ErrWaitExceedsDeadline
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(wait)) {
+		l.cancel()
+		return fmt.Errorf("rate limiter wait of %s: %w", wait, ErrWaitExceedsDeadline)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// cancel gives back a token reserved by Wait
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	if now.After(l.last) {
+		l.last = now
+	}
+}
+
+// ThrottledClient is an HTTPClient decorator waiting on a RateLimiter before
+// sending each request. The wait honours the request's context
+type ThrottledClient struct {
+	client  HTTPClient
+	limiter *RateLimiter
+}
+
+// NewThrottledClient wraps c so that it sends requests no faster than l allows
+func NewThrottledClient(c HTTPClient, l *RateLimiter) (*ThrottledClient, error) {
+	if c == nil {
+		return nil, fmt.Errorf("client.NewThrottledClient: nil HTTPClient")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("client.NewThrottledClient: nil RateLimiter")
+	}
+
+	return &ThrottledClient{client: c, limiter: l}, nil
+}
+
+func (c *ThrottledClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return c.client.Do(req)
+}
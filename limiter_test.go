@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestNewRateLimiterValidation(t *testing.T) {
+	_, err := NewRateLimiter(0, 1)
+	assert.Error(t, err)
+
+	_, err = NewRateLimiter(1, 0)
+	assert.Error(t, err)
+
+	l, err := NewRateLimiter(0.5, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l, err := NewRateLimiter(2, 3)
+	require.NoError(t, err)
+	l.now = clock.Now
+
+	// Full bucket allows a burst
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 2 rps refills a token every 500ms
+	clock.Advance(400 * time.Millisecond)
+	assert.False(t, l.Allow())
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// The bucket never holds more than the burst size
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l, err := NewRateLimiter(50, 1)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Wait(ctx))
+	}
+
+	// The first token comes from the bucket, the next 3 at 20ms intervals
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(55*time.Millisecond))
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	l, err := NewRateLimiter(0.01, 1)
+	require.NoError(t, err)
+
+	require.True(t, l.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = l.Wait(ctx)
+	assert.True(t, errors.Is(err, ErrWaitExceedsDeadline))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "must fail early, not after 100s")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+
+	// Reservations of failed waits are handed back
+	l.mu.Lock()
+	assert.InDelta(t, 0, l.tokens, 0.01)
+	l.mu.Unlock()
+}
+
+func TestThrottledClientSharesLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l, err := NewRateLimiter(1, 2)
+	require.NoError(t, err)
+	l.now = clock.Now
+
+	var mu sync.Mutex
+	sent := 0
+	mock := &MockClient{DoImpl: func(*http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}}
+
+	a, err := NewThrottledClient(mock, l)
+	require.NoError(t, err)
+	b, err := NewThrottledClient(mock, l)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, err)
+
+	_, err = a.Do(req)
+	require.NoError(t, err)
+	_, err = b.Do(req)
+	require.NoError(t, err)
+
+	// The bucket shared by both clients is now empty and the clock frozen
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	resp, err := a.Do(req.WithContext(ctx))
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, 2, sent)
+}
+
+func TestNewThrottledClientValidation(t *testing.T) {
+	l, err := NewRateLimiter(1, 1)
+	require.NoError(t, err)
+
+	_, err = NewThrottledClient(nil, l)
+	assert.Error(t, err)
+
+	_, err = NewThrottledClient(&MockClient{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExpiryDelta  = 30 * time.Second
+	defaultTokenTimeout = 30 * time.Second
+)
+
+// OAuth2Config describes how to obtain tokens through the OAuth2
+// client credentials grant (RFC 6749 section 4.4)
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// ExpiryDelta is how long before their expiry tokens are refreshed,
+	// so that they do not expire while a request is in flight.
+	// Defaults to 30 seconds
+	ExpiryDelta time.Duration
+
+	// Timeout bounds every token request, which is shared by all the
+	// requests waiting on it. Defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// OAuth2Client is an HTTPClient decorator authenticating requests with a
+// bearer token obtained through the client credentials grant. Tokens are
+// cached until shortly before they expire. Concurrent requests needing a new
+// token share a single token request. A request answered with 401
+// Unauthorized is retried once with a fresh token
+type OAuth2Client struct {
+	client HTTPClient
+	cfg    OAuth2Config
+
+	mu       sync.Mutex
+	token    *oauth2Token
+	inflight *tokenCall
+
+	// now is swapped out by tests
+	now func() time.Time
+}
+
+type oauth2Token struct {
+	accessToken string
+	expiry      time.Time
+}
+
+// tokenCall is a token request shared by every caller waiting on it
+type tokenCall struct {
+	done  chan struct{}
+	token *oauth2Token
+	err   error
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewOAuth2Client wraps c so that every request it sends carries a bearer
+// token. Tokens are requested from cfg.TokenURL using c as well
+func NewOAuth2Client(c HTTPClient, cfg OAuth2Config) (*OAuth2Client, error) {
+	if c == nil {
+		return nil, fmt.Errorf("client.NewOAuth2Client: nil HTTPClient")
+	}
+
+	u, err := url.Parse(cfg.TokenURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client.NewOAuth2Client: invalid token url: %q", cfg.TokenURL)
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("client.NewOAuth2Client: empty client id")
+	}
+	if cfg.ExpiryDelta < 0 {
+		return nil, fmt.Errorf("client.NewOAuth2Client: negative expiry delta: %s", cfg.ExpiryDelta)
+	}
+	if cfg.ExpiryDelta == 0 {
+		cfg.ExpiryDelta = defaultExpiryDelta
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("client.NewOAuth2Client: negative timeout: %s", cfg.Timeout)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTokenTimeout
+	}
+	cfg.Scopes = append([]string(nil), cfg.Scopes...)
+
+	return &OAuth2Client{client: c, cfg: cfg, now: time.Now}, nil
+}
+
+func (c *OAuth2Client) Do(req *http.Request) (*http.Response, error) {
+	token, err := c.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token might have been revoked before its expiry. Retry once with
+	// a fresh one, provided the request body can be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	c.invalidate(token)
+	token, err = c.Token(req.Context())
+	if err != nil {
+		return resp, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		req.Body = body
+	}
+
+	resp.Body.Close()
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.client.Do(req)
+}
+
+// Token returns a valid access token, requesting a new one when the cached
+// token is missing or about to expire
+func (c *OAuth2Client) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.token != nil && (c.token.expiry.IsZero() || c.now().Add(c.cfg.ExpiryDelta).Before(c.token.expiry)) {
+		token := c.token.accessToken
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	call := c.inflight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		c.inflight = call
+		go c.fetch(ctx, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return "", call.err
+		}
+		return call.token.accessToken, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// invalidate drops the cached token, unless it was already replaced
+func (c *OAuth2Client) invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != nil && c.token.accessToken == token {
+		c.token = nil
+	}
+}
+
+// fetch requests a token on behalf of every caller of call. The request keeps
+// the values of the context of the caller triggering it but not its
+// cancellation, so that one caller giving up does not fail the others. It is
+// bounded by the configured timeout instead
+func (c *OAuth2Client) fetch(ctx context.Context, call *tokenCall) {
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, c.cfg.Timeout)
+	defer cancel()
+
+	token, err := c.requestToken(ctx)
+
+	c.mu.Lock()
+	if err == nil {
+		c.token = token
+	}
+	c.inflight = nil
+	c.mu.Unlock()
+
+	call.token, call.err = token, err
+	close(call.done)
+}
+
+// detachedContext carries the values of its parent but is never cancelled
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+func (c *OAuth2Client) requestToken(ctx context.Context) (*oauth2Token, error) {
+	// Lifetimes count from when the token was requested, the
+	// safe side should the response be slow to arrive
+	requested := c.now()
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response body: %w", err)
+	}
+
+	var tr tokenResponse
+	jsonErr := json.Unmarshal(b, &tr)
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, ErrorCode: tr.Error, ErrorMessage: tr.ErrorDescription}
+		if jsonErr != nil {
+			apiErr.ErrorMessage = string(b)
+		}
+		return nil, fmt.Errorf("token request failed: %w", apiErr)
+	}
+
+	if jsonErr != nil {
+		return nil, fmt.Errorf("unmarshaling token response err: %w", jsonErr)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("token response without access token")
+	}
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type: %q", tr.TokenType)
+	}
+
+	// Tokens without a lifetime never expire and are only refreshed on a 401
+	token := &oauth2Token{accessToken: tr.AccessToken}
+	if tr.ExpiresIn > 0 {
+		token.expiry = requested.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
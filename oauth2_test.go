@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer issues tokens numbered in the order they are requested
+type tokenServer struct {
+	*httptest.Server
+	issued    int32
+	expiresIn int
+	delay     time.Duration
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	ts := &tokenServer{expiresIn: expiresIn}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Credentials are form encoded before basic auth, RFC 6749 section 2.3.1
+		id, secret, ok := r.BasicAuth()
+		secret, _ = url.QueryUnescape(secret)
+		if !ok || id != "client-id" || secret != "s3cr%t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "bad credentials"}`)
+			return
+		}
+
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "accounts:read accounts:write", r.PostForm.Get("scope"))
+
+		time.Sleep(ts.delay)
+		n := atomic.AddInt32(&ts.issued, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, n, ts.expiresIn)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// apiServer echoes the bearer token back, rejecting the ones listed as revoked
+func apiServer(t *testing.T, revoked ...string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for _, rt := range revoked {
+			if token == rt {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", token, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestOAuth2Client(t *testing.T, ts *tokenServer) *OAuth2Client {
+	c, err := NewOAuth2Client(&http.Client{}, OAuth2Config{
+		TokenURL:     ts.URL + "/oauth2/token",
+		ClientID:     "client-id",
+		ClientSecret: "s3cr%t",
+		Scopes:       []string{"accounts:read", "accounts:write"},
+		ExpiryDelta:  10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	return c
+}
+
+func doGet(t *testing.T, c HTTPClient, url string) (int, string) {
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
+
+func TestOAuth2ClientCachesToken(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	api := apiServer(t)
+	c := newTestOAuth2Client(t, ts)
+
+	for i := 0; i < 3; i++ {
+		code, body := doGet(t, c, api.URL)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "token-1 ", body)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ts.issued))
+}
+
+func TestOAuth2ClientRefreshesBeforeExpiry(t *testing.T) {
+	ts := newTokenServer(t, 60)
+	api := apiServer(t)
+	c := newTestOAuth2Client(t, ts)
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	_, body := doGet(t, c, api.URL)
+	assert.Equal(t, "token-1 ", body)
+
+	// Still 11s to go, more than the 10s expiry delta
+	now = now.Add(49 * time.Second)
+	_, body = doGet(t, c, api.URL)
+	assert.Equal(t, "token-1 ", body)
+
+	now = now.Add(time.Second)
+	_, body = doGet(t, c, api.URL)
+	assert.Equal(t, "token-2 ", body)
+}
+
+func TestOAuth2ClientSingleFlight(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	ts.delay = 50 * time.Millisecond
+	api := apiServer(t)
+	c := newTestOAuth2Client(t, ts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, body := doGet(t, c, api.URL)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "token-1 ", body)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ts.issued))
+}
+
+func TestOAuth2ClientRetriesOnceOnUnauthorized(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	api := apiServer(t, "token-1")
+	c := newTestOAuth2Client(t, ts)
+
+	req, err := http.NewRequest("POST", api.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "token-2 payload", string(body), "body must be replayed on retry")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&ts.issued))
+}
+
+func TestOAuth2ClientGivesUpAfterOneRetry(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	api := apiServer(t, "token-1", "token-2", "token-3")
+	c := newTestOAuth2Client(t, ts)
+
+	code, _ := doGet(t, c, api.URL)
+
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&ts.issued))
+}
+
+func TestOAuth2ClientTokenErrors(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+
+	c, err := NewOAuth2Client(&http.Client{}, OAuth2Config{
+		TokenURL:     ts.URL,
+		ClientID:     "client-id",
+		ClientSecret: "wrong",
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "http://localhost", nil)
+	require.NoError(t, err)
+
+	resp, err := c.Do(req)
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, &APIError{
+		ErrorCode:    "invalid_client",
+		ErrorMessage: "bad credentials",
+		StatusCode:   http.StatusUnauthorized,
+	})
+}
+
+func TestOAuth2ClientTokenHonoursContext(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	ts.delay = 200 * time.Millisecond
+	c := newTestOAuth2Client(t, ts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Token(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+type ctxKey struct{}
+
+// ctxValueClient records the ctxKey value of the requests it sends
+type ctxValueClient struct {
+	values chan interface{}
+}
+
+func (c *ctxValueClient) Do(req *http.Request) (*http.Response, error) {
+	c.values <- req.Context().Value(ctxKey{})
+	return http.DefaultClient.Do(req)
+}
+
+func TestOAuth2ClientTokenRequestTimeout(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Hang until the client gives up
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	recorder := &ctxValueClient{values: make(chan interface{}, 2)}
+	c, err := NewOAuth2Client(recorder, OAuth2Config{
+		TokenURL: ts.URL,
+		ClientID: "client-id",
+		Timeout:  50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-1")
+	_, err = c.Token(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Equal(t, "trace-1", <-recorder.values, "values of the triggering request are kept")
+
+	// The hung request does not block later callers
+	token, err := c.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Nil(t, <-recorder.values)
+}
+
+func TestOAuth2ClientTokenOutlivesCaller(t *testing.T) {
+	ts := newTokenServer(t, 3600)
+	ts.delay = 50 * time.Millisecond
+	c := newTestOAuth2Client(t, ts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Token(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	token, err := c.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token, "the first request completed for the next caller")
+}
+
+func TestNewOAuth2ClientValidation(t *testing.T) {
+	tests := map[string]OAuth2Config{
+		"missing token url":  {ClientID: "id"},
+		"relative token url": {TokenURL: "/token", ClientID: "id"},
+		"missing client id":  {TokenURL: "https://auth.example.com/token"},
+		"negative delta":     {TokenURL: "https://auth.example.com/token", ClientID: "id", ExpiryDelta: -1},
+		"negative timeout":   {TokenURL: "https://auth.example.com/token", ClientID: "id", Timeout: -1},
+	}
+
+	for name, cfg := range tests {
+		cfg := cfg
+		t.Run(name, func(t *testing.T) {
+			c, err := NewOAuth2Client(&http.Client{}, cfg)
+			assert.Error(t, err)
+			assert.Nil(t, c)
+		})
+	}
+
+	_, err := NewOAuth2Client(nil, OAuth2Config{TokenURL: "https://auth.example.com/token", ClientID: "id"})
+	assert.Error(t, err)
+}
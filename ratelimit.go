@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resetEpochThreshold separates X-RateLimit-Reset values given as delta
+// seconds from values given as a unix timestamp. Nobody rate limits for
+// more than 30 years, and no unix timestamp is that small anymore
+const resetEpochThreshold = 1e9
+
+// RateLimit holds the rate limiting hints a server sent along a response
+type RateLimit struct {
+	// RetryAfter is how long the server asked clients to wait before
+	// retrying, from the Retry-After header. Zero when not sent
+	RetryAfter time.Duration
+
+	// Limit is the request quota of the current window. -1 when not sent
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	// -1 when not sent
+	Remaining int
+
+	// Reset is when the current window ends and the quota is replenished.
+	// The zero time when not sent
+	Reset time.Time
+}
+
+// ParseRateLimit reads the Retry-After header, both in its delta-seconds and
+// HTTP-date forms, along with the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers or their unprefixed RateLimit-* equivalents.
+// X-RateLimit-Reset is accepted as a unix timestamp or as delta seconds.
+// now is the time relative delays are computed against. Malformed headers
+// are ignored
+func ParseRateLimit(h http.Header, now time.Time) RateLimit {
+	rl := RateLimit{
+		Limit:     headerInt(h, "X-RateLimit-Limit", "RateLimit-Limit"),
+		Remaining: headerInt(h, "X-RateLimit-Remaining", "RateLimit-Remaining"),
+	}
+
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if secs > 0 {
+				rl.RetryAfter = time.Duration(secs) * time.Second
+			}
+		} else if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			rl.RetryAfter = at.Sub(now)
+		}
+	}
+
+	if reset := headerInt(h, "X-RateLimit-Reset", "RateLimit-Reset"); reset >= 0 {
+		if reset >= resetEpochThreshold {
+			rl.Reset = time.Unix(int64(reset), 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	return rl
+}
+
+// Wait returns how long the server wants clients to wait before sending
+// another request: the Retry-After delay when sent, or else the time left
+// until Reset when the quota is exhausted. ok is false when the server gave
+// no hint, or when Reset has already passed and the hint is stale
+func (l RateLimit) Wait(now time.Time) (d time.Duration, ok bool) {
+	if l.RetryAfter > 0 {
+		return l.RetryAfter, true
+	}
+
+	if l.Remaining == 0 && !l.Reset.IsZero() {
+		if d := l.Reset.Sub(now); d > 0 {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
+
+func headerInt(h http.Header, keys ...string) int {
+	for _, k := range keys {
+		v := strings.TrimSpace(h.Get(k))
+		if v == "" {
+			continue
+		}
+
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return -1
+}
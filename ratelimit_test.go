@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC)
+
+	tests := map[string]struct {
+		headers map[string]string
+		want    RateLimit
+	}{
+		"no headers": {
+			want: RateLimit{Limit: -1, Remaining: -1},
+		},
+		"retry after seconds": {
+			headers: map[string]string{"Retry-After": "120"},
+			want:    RateLimit{RetryAfter: 2 * time.Minute, Limit: -1, Remaining: -1},
+		},
+		"retry after http date": {
+			headers: map[string]string{"Retry-After": "Tue, 25 May 2021 04:29:41 GMT"},
+			want:    RateLimit{RetryAfter: 30 * time.Second, Limit: -1, Remaining: -1},
+		},
+		"retry after date in the past": {
+			headers: map[string]string{"Retry-After": "Tue, 25 May 2021 04:00:00 GMT"},
+			want:    RateLimit{Limit: -1, Remaining: -1},
+		},
+		"malformed retry after": {
+			headers: map[string]string{"Retry-After": "soon", "X-RateLimit-Remaining": "many"},
+			want:    RateLimit{Limit: -1, Remaining: -1},
+		},
+		"reset as unix timestamp": {
+			headers: map[string]string{
+				"X-RateLimit-Limit":     "100",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1621917000",
+			},
+			want: RateLimit{Limit: 100, Remaining: 0, Reset: time.Unix(1621917000, 0)},
+		},
+		"reset as delta seconds": {
+			headers: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "3",
+				"RateLimit-Reset":     "15",
+			},
+			want: RateLimit{Limit: 10, Remaining: 3, Reset: now.Add(15 * time.Second)},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tc.headers {
+				h.Set(k, v)
+			}
+
+			assert.Equal(t, tc.want, ParseRateLimit(h, now))
+		})
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC)
+
+	tests := map[string]struct {
+		limit RateLimit
+		wait  time.Duration
+		ok    bool
+	}{
+		"no hints":   {limit: RateLimit{Limit: -1, Remaining: -1}},
+		"quota left": {limit: RateLimit{Remaining: 5, Reset: now.Add(time.Minute)}},
+		"retry after": {
+			limit: RateLimit{RetryAfter: 3 * time.Second, Remaining: 0, Reset: now.Add(time.Minute)},
+			wait:  3 * time.Second, ok: true,
+		},
+		"quota exhausted": {
+			limit: RateLimit{Remaining: 0, Reset: now.Add(10 * time.Second)},
+			wait:  10 * time.Second, ok: true,
+		},
+		"quota already reset": {
+			limit: RateLimit{Remaining: 0, Reset: now.Add(-time.Second)},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			wait, ok := tc.limit.Wait(now)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.wait, wait)
+		})
+	}
+}
+
+func TestRateLimitedErrorUnwrapsAPIError(t *testing.T) {
+	apiErr := &APIError{ErrorMessage: "slow down", StatusCode: http.StatusTooManyRequests}
+	var err error = &RateLimitedError{RateLimit: RateLimit{RetryAfter: time.Second}, Err: apiErr}
+
+	var got *APIError
+	assert.True(t, errors.As(err, &got))
+	assert.ErrorIs(t, err, &APIError{ErrorMessage: "slow down", StatusCode: http.StatusTooManyRequests})
+	assert.Contains(t, err.Error(), "retry_after=1s")
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// JitterStrategy randomises retry delays. An additional jitter is necessary
+// so as to avoid many clients retrying at the exact same time. The many
+// concurrent requests can exhaust server TCP connection resources
+type JitterStrategy interface {
+	// Jitter returns the delay d with some randomness applied
+	Jitter(d time.Duration) time.Duration
+}
+
+// NoJitter leaves retry delays untouched
+type NoJitter struct{}
+
+func (NoJitter) Jitter(d time.Duration) time.Duration { return d }
+
+// AdditiveJitter adds a random duration in [0, Max) on top of retry delays
+type AdditiveJitter struct {
+	Max  time.Duration
+	Rand RandSource
+}
+
+func (j AdditiveJitter) Jitter(d time.Duration) time.Duration {
+	if j.Max <= 0 {
+		return d
+	}
+	return d + time.Duration(randInt63n(j.Rand, int64(j.Max)))
+}
+
+// RetryPolicy describes how idempotent requests are retried by resource APIs.
+// Requests are retried on network errors and on the RetryableStatusCodes.
+// A wait requested by the server through Retry-After or rate limit headers
+// takes precedence over the Backoff delay, unless it exceeds MaxDelay in
+// which case the request is not retried at all
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a request is sent, including
+	// the first attempt. Values below 2 disable retries
+	MaxAttempts int
+
+	// BaseDelay is the delay the Backoff strategy starts from
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay before a retry, jitter included.
+	// Zero leaves delays uncapped
+	MaxDelay time.Duration
+
+	// Backoff computes the delay before each retry. A nil Backoff
+	// behaves like ConstantBackoff
+	Backoff Backoff
+
+	// Jitter randomises the delays computed by Backoff. A nil Jitter behaves
+	// like NoJitter, which suits strategies that already are random such as
+	// FullJitterBackoff and DecorrelatedJitterBackoff
+	Jitter JitterStrategy
+
+	// RetryableStatusCodes are the response status codes deemed safe to retry
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns the policy resource APIs use unless configured
+// otherwise: 5 attempts with an exponential backoff starting at 2 seconds
+// plus up to a second of jitter, retrying 429, 500, 502, 503 and 504 responses
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          5,
+		BaseDelay:            2 * time.Second,
+		MaxDelay:             30 * time.Second,
+		Backoff:              ExponentialBackoff{},
+		Jitter:               AdditiveJitter{Max: time.Second},
+		RetryableStatusCodes: []int{429, 500, 502, 503, 504},
+	}
+}
+
+// NoRetryPolicy returns a policy that sends every request exactly once
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// Validate reports the first inconsistency found in the policy
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("negative max attempts: %d", p.MaxAttempts)
+	}
+	if p.BaseDelay < 0 {
+		return fmt.Errorf("negative base delay: %s", p.BaseDelay)
+	}
+	if p.MaxDelay < 0 {
+		return fmt.Errorf("negative max delay: %s", p.MaxDelay)
+	}
+	if p.MaxDelay > 0 && p.BaseDelay > p.MaxDelay {
+		return fmt.Errorf("base delay %s exceeds max delay %s", p.BaseDelay, p.MaxDelay)
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retryable status code: %d", code)
+		}
+	}
+
+	return nil
+}
+
+// IsRetryableStatus reports whether a response with the given status code
+// may be retried
+func (p RetryPolicy) IsRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to sleep before the given retry, 1 being the first.
+// prev is the delay returned for the previous retry, zero before the first
+func (p RetryPolicy) Delay(retry int, prev time.Duration) time.Duration {
+	var backoff Backoff = ConstantBackoff{}
+	if p.Backoff != nil {
+		backoff = p.Backoff
+	}
+
+	d := backoff.Backoff(retry, p.BaseDelay, p.MaxDelay, prev)
+	if p.Jitter != nil {
+		d = p.Jitter.Jitter(d)
+	}
+
+	return capDelay(d, p.MaxDelay)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := map[string]struct {
+		policy RetryPolicy
+		valid  bool
+	}{
+		"default":               {policy: DefaultRetryPolicy(), valid: true},
+		"no retry":              {policy: NoRetryPolicy(), valid: true},
+		"zero value":            {policy: RetryPolicy{}, valid: true},
+		"negative attempts":     {policy: RetryPolicy{MaxAttempts: -1}},
+		"negative base delay":   {policy: RetryPolicy{BaseDelay: -time.Second}},
+		"negative max delay":    {policy: RetryPolicy{MaxDelay: -time.Second}},
+		"base exceeds max":      {policy: RetryPolicy{BaseDelay: 2 * time.Second, MaxDelay: time.Second}},
+		"invalid status code":   {policy: RetryPolicy{RetryableStatusCodes: []int{503, 600}}},
+		"uncapped large delays": {policy: RetryPolicy{BaseDelay: time.Hour}, valid: true},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyIsRetryableStatus(t *testing.T) {
+	p := DefaultRetryPolicy()
+
+	for _, code := range []int{500, 502, 503, 504} {
+		assert.True(t, p.IsRetryableStatus(code), code)
+	}
+	for _, code := range []int{200, 400, 404, 409, 501} {
+		assert.False(t, p.IsRetryableStatus(code), code)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 2 * time.Second, MaxDelay: 3 * time.Second, Jitter: NoJitter{}}
+	assert.Equal(t, 2*time.Second, p.Delay(1, 0))
+	assert.Equal(t, 2*time.Second, p.Delay(4, 0))
+
+	p.Jitter = nil
+	assert.Equal(t, 2*time.Second, p.Delay(1, 0))
+
+	p.Jitter = AdditiveJitter{Max: time.Second}
+	for i := 1; i <= 100; i++ {
+		d := p.Delay(i, 0)
+		assert.GreaterOrEqual(t, int64(d), int64(2*time.Second))
+		assert.Less(t, int64(d), int64(3*time.Second))
+	}
+
+	p.Jitter = AdditiveJitter{Max: 5 * time.Second}
+	for i := 1; i <= 100; i++ {
+		assert.LessOrEqual(t, int64(p.Delay(i, 0)), int64(3*time.Second))
+	}
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AlgorithmRSASHA256 signs requests with RSASSA-PKCS1-v1_5 over SHA-256
+	AlgorithmRSASHA256 = "rsa-sha256"
+
+	// AlgorithmEd25519 signs requests with Ed25519
+	AlgorithmEd25519 = "ed25519"
+)
+
+// Signer signs requests following the HTTP Signatures draft
+// (draft-cavage-http-signatures-12). GET and DELETE requests are signed over
+// (request-target), host and date. Requests with a body additionally cover
+// content-type, content-length and the SHA-256 digest of the body
+type Signer struct {
+	keyID     string
+	algorithm string
+	sign      func(msg []byte) ([]byte, error)
+}
+
+// NewRSASigner creates a Signer using the rsa-sha256 algorithm.
+// keyID identifies the public key the platform verifies signatures with
+func NewRSASigner(keyID string, key *rsa.PrivateKey) (*Signer, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("client.NewRSASigner: empty key id")
+	}
+	if key == nil {
+		return nil, fmt.Errorf("client.NewRSASigner: nil private key")
+	}
+
+	return &Signer{
+		keyID:     keyID,
+		algorithm: AlgorithmRSASHA256,
+		sign: func(msg []byte) ([]byte, error) {
+			h := sha256.Sum256(msg)
+			return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+		},
+	}, nil
+}
+
+// NewEd25519Signer creates a Signer using the ed25519 algorithm.
+// keyID identifies the public key the platform verifies signatures with
+func NewEd25519Signer(keyID string, key ed25519.PrivateKey) (*Signer, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("client.NewEd25519Signer: empty key id")
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("client.NewEd25519Signer: invalid private key size: %d", len(key))
+	}
+
+	return &Signer{
+		keyID:     keyID,
+		algorithm: AlgorithmEd25519,
+		sign: func(msg []byte) ([]byte, error) {
+			return ed25519.Sign(key, msg), nil
+		},
+	}, nil
+}
+
+// NewSignerFromPEM creates a Signer from a PEM encoded RSA or Ed25519 private
+// key, in either PKCS #8 or, for RSA, PKCS #1 form
+func NewSignerFromPEM(keyID string, data []byte) (*Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("client.NewSignerFromPEM: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewRSASigner(keyID, key)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("client.NewSignerFromPEM: unsupported private key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return NewRSASigner(keyID, k)
+	case ed25519.PrivateKey:
+		return NewEd25519Signer(keyID, k)
+	default:
+		return nil, fmt.Errorf("client.NewSignerFromPEM: unsupported private key type: %T", key)
+	}
+}
+
+// Sign sets the Digest header for requests with a body and the Signature
+// header. A Date header is added when missing, since the signature covers it.
+// The request body is buffered so that it can still be sent afterwards
+func (s *Signer) Sign(req *http.Request) error {
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	headers := []string{"(request-target)", "host", "date"}
+
+	body, err := readBody(req)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	if body != nil {
+		digest := sha256.Sum256(body)
+		req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		headers = append(headers, "content-type", "content-length", "digest")
+	}
+
+	msg := signingString(req, headers)
+	sig, err := s.sign([]byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyID, s.algorithm, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig),
+	))
+
+	return nil
+}
+
+// signingString builds the string the signature is computed over,
+// one `name: value` line per signed header
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		var v string
+		switch h {
+		case "(request-target)":
+			v = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			v = req.Host
+			if v == "" {
+				v = req.URL.Host
+			}
+		default:
+			v = strings.TrimSpace(req.Header.Get(h))
+		}
+		lines = append(lines, h+": "+v)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// readBody returns the request body, nil when there is none, leaving the
+// request ready to be sent. GetBody is preferred so that requests retried
+// with their original body are signed consistently
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		return io.ReadAll(rc)
+	}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.ContentLength = int64(len(b))
+
+	return b, nil
+}
+
+// SigningClient is an HTTPClient decorator signing every request with a Signer
+// before sending it
+type SigningClient struct {
+	client HTTPClient
+	signer *Signer
+}
+
+// NewSigningClient wraps c so that every request it sends is signed by s
+func NewSigningClient(c HTTPClient, s *Signer) (*SigningClient, error) {
+	if c == nil {
+		return nil, fmt.Errorf("client.NewSigningClient: nil HTTPClient")
+	}
+	if s == nil {
+		return nil, fmt.Errorf("client.NewSigningClient: nil Signer")
+	}
+
+	return &SigningClient{client: c, signer: s}, nil
+}
+
+func (c *SigningClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.signer.Sign(req); err != nil {
+		return nil, err
+	}
+
+	return c.client.Do(req)
+}
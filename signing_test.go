@@ -0,0 +1,188 @@
+package client
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected signatures were computed independently with openssl over the
+// signing strings of the requests below, e.g.
+//
+//	openssl dgst -sha256 -sign testdata/signing_rsa.pem get.txt | base64
+//	openssl pkeyutl -sign -inkey testdata/signing_ed25519.pem -rawin -in get.txt | base64
+//
+// The Ed25519 key is the RFC 8032 section 7.1 TEST 1 key.
+const (
+	vectorDate = "Tue, 25 May 2021 04:29:11 GMT"
+	vectorBody = `{"data":{"type":"accounts"}}`
+
+	rsaGetSignature = "b7dtxHhOOvhO6ekDh/G2WDRbY6HOUwrPODG/mx1bw6wAxh6MO2ncxm4GoE92x2XmFD6RZG94NOahpecNH510Tk3ip" +
+		"kX+b+HP2gl6ADGqmB3ScNslzAvBbw4+WMzhz3HVEwp+JzhmpN9LfFsqCtSqnTmka81slez50v+hyN76xdXBghbXnLnGYzYAoL" +
+		"vLTn647GcUeQhB0HrJRcPOEiJ9N9UT4fpqVWK0gGwFs7uufIQFrBffjhAzeM/pW0ub+9IKdKHrWuRDBOIhzQTY7lY9hohyoVfI" +
+		"L+LHAWtlwnfrfuC7W48S7EYdsNd3mV0OV+VOkCDvlJPUBrVP1IJGP56X5w=="
+	rsaPostSignature = "dblt/VzWe2ihGqtuCyoHK7ttMA0sAH4aqWd8eC6NNxHmOe7z2+xjTmc2pokz/AwVESq4lmq6iZm9qgaF60jzSRfo" +
+		"WZCnC2PvX3rtWPdCt6AuaZ1LtfUDYZqSkX1/cWt37tqbNb3ql6gZ6TkzvLdiOAJGl2Pr/UTFR99aqh5Ms6oN/iVQrCx4dhfPH" +
+		"IX2VAtJKncGtjCtlj+Mf6HIjcoSRHJ/vuePkwlSrlFWRe7vMU6NWvMCzWqiQgGmy6luZJP08Lvn2s1I+/UK9uUpVVX2Blh78DV" +
+		"BiM7IKadt9Djv39dVgCE7Jk7eJPHb0QkCynjXkcU41VrHidXiiPzaJvAF/g=="
+	ed25519GetSignature  = "eE8rjXkb3x8WYMZ6TorEDNOrsbf6gPpFe2esIUjDp0h2ieKotBB6u+EHIsGGnd7zZhXTzIWwG7tsrBYXCDeoAA=="
+	ed25519PostSignature = "Fvt2OG2zeXDF8MnOcApbpwAgveE5C69DxQ/QJqtfJ0y/Ko66jmg5Zjt3etO4NSYYPO1vzUtdLXYb0vqJpBZBAw=="
+
+	vectorDigest = "SHA-256=qLRrz0B0CIDeUJNUYC4zYDRdNCYDuHg0sG98ugY+kHU="
+)
+
+func vectorRequests(t *testing.T) (get, post *http.Request) {
+	get, err := http.NewRequest("GET",
+		"https://api.example.com/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", nil)
+	require.NoError(t, err)
+	get.Header.Set("Date", vectorDate)
+
+	post, err = http.NewRequest("POST", "https://api.example.com/v1/organisation/accounts",
+		bytes.NewReader([]byte(vectorBody)))
+	require.NoError(t, err)
+	post.Header.Set("Date", vectorDate)
+	post.Header.Set("Content-Type", "application/vnd.api+json")
+
+	return get, post
+}
+
+func loadSigner(t *testing.T, path string) *Signer {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	s, err := NewSignerFromPEM("key-1", data)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSignerKnownVectors(t *testing.T) {
+	tests := map[string]struct {
+		keyFile   string
+		algorithm string
+		getSig    string
+		postSig   string
+	}{
+		"rsa-sha256": {
+			keyFile: "testdata/signing_rsa.pem", algorithm: "rsa-sha256",
+			getSig: rsaGetSignature, postSig: rsaPostSignature,
+		},
+		"ed25519": {
+			keyFile: "testdata/signing_ed25519.pem", algorithm: "ed25519",
+			getSig: ed25519GetSignature, postSig: ed25519PostSignature,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := loadSigner(t, tc.keyFile)
+			get, post := vectorRequests(t)
+
+			require.NoError(t, s.Sign(get))
+			assert.Empty(t, get.Header.Get("Digest"))
+			assert.Equal(t,
+				`keyId="key-1",algorithm="`+tc.algorithm+`",headers="(request-target) host date",`+
+					`signature="`+tc.getSig+`"`,
+				get.Header.Get("Signature"))
+
+			require.NoError(t, s.Sign(post))
+			assert.Equal(t, vectorDigest, post.Header.Get("Digest"))
+			assert.Equal(t, "28", post.Header.Get("Content-Length"))
+			assert.Equal(t,
+				`keyId="key-1",algorithm="`+tc.algorithm+`",`+
+					`headers="(request-target) host date content-type content-length digest",`+
+					`signature="`+tc.postSig+`"`,
+				post.Header.Get("Signature"))
+
+			// The body must still be sendable after signing
+			body, err := io.ReadAll(post.Body)
+			require.NoError(t, err)
+			assert.Equal(t, vectorBody, string(body))
+		})
+	}
+}
+
+func TestSignerRFC8032Key(t *testing.T) {
+	seed, err := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	require.NoError(t, err)
+
+	s, err := NewEd25519Signer("key-1", ed25519.NewKeyFromSeed(seed))
+	require.NoError(t, err)
+
+	get, _ := vectorRequests(t)
+	require.NoError(t, s.Sign(get))
+
+	assert.Contains(t, get.Header.Get("Signature"), ed25519GetSignature)
+}
+
+func TestSignerBufferedBody(t *testing.T) {
+	s := loadSigner(t, "testdata/signing_ed25519.pem")
+
+	_, post := vectorRequests(t)
+	post.GetBody = nil
+	post.Body = io.NopCloser(bytes.NewReader([]byte(vectorBody)))
+
+	require.NoError(t, s.Sign(post))
+	assert.Contains(t, post.Header.Get("Signature"), ed25519PostSignature)
+
+	for i := 0; i < 2; i++ {
+		rc, err := post.GetBody()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, vectorBody, string(body))
+	}
+}
+
+func TestSignerSetsMissingDate(t *testing.T) {
+	s := loadSigner(t, "testdata/signing_ed25519.pem")
+
+	req, err := http.NewRequest("DELETE", "https://api.example.com/v1/organisation/accounts/x?version=0", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Sign(req))
+	assert.NotEmpty(t, req.Header.Get("Date"))
+	assert.Contains(t, req.Header.Get("Signature"), `headers="(request-target) host date"`)
+}
+
+func TestSigningClient(t *testing.T) {
+	var got *http.Request
+	mock := &MockClient{DoImpl: func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}}
+
+	c, err := NewSigningClient(mock, loadSigner(t, "testdata/signing_rsa.pem"))
+	require.NoError(t, err)
+
+	get, _ := vectorRequests(t)
+	_, err = c.Do(get)
+
+	require.NoError(t, err)
+	assert.Contains(t, got.Header.Get("Signature"), rsaGetSignature)
+}
+
+func TestSignerConstructorsValidation(t *testing.T) {
+	_, err := NewRSASigner("", nil)
+	assert.Error(t, err)
+
+	_, err = NewEd25519Signer("key-1", ed25519.PrivateKey{1, 2, 3})
+	assert.Error(t, err)
+
+	_, err = NewSignerFromPEM("key-1", []byte("not a pem"))
+	assert.Error(t, err)
+
+	_, err = NewSigningClient(nil, &Signer{})
+	assert.Error(t, err)
+
+	_, err = NewSigningClient(&MockClient{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimestampFormat is the layout the platform uses for timestamps,
+// e.g. 2021-05-25T04:29:11.898Z
+const TimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// Timestamp is a time.Time read from and written to JSON the way the platform
+// formats timestamps. Any RFC 3339 timestamp is accepted, whatever its precision.
+// Parsed timestamps are written back in the layout they were read in, so that
+// they round trip unchanged. Other UTC timestamps with at most millisecond
+// precision are written in TimestampFormat, anything else in time.RFC3339Nano
+type Timestamp struct {
+	time.Time
+
+	// layout is the layout the timestamp was parsed from, when it differs
+	// from the one MarshalJSON picks by default
+	layout string
+}
+
+// NewTimestamp wraps t in a Timestamp
+func NewTimestamp(t time.Time) *Timestamp {
+	return &Timestamp{Time: t}
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.format() + `"`), nil
+}
+
+func (t Timestamp) format() string {
+	layout := t.layout
+	if layout == "" {
+		layout = time.RFC3339Nano
+		if t.Location() == time.UTC && t.Nanosecond()%int(time.Millisecond) == 0 {
+			layout = TimestampFormat
+		}
+	}
+
+	return t.Format(layout)
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid timestamp: %s", data)
+	}
+
+	value := string(data[1 : len(data)-1])
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	*t = Timestamp{Time: parsed}
+	if t.format() != value {
+		t.layout = layoutOf(value)
+	}
+	return nil
+}
+
+// layoutOf returns the layout formatting timestamps the way the RFC 3339
+// timestamp value is, keeping its number of fractional digits and its offset
+func layoutOf(value string) string {
+	var b strings.Builder
+	b.WriteString("2006-01-02T15:04:05")
+
+	rest := value[len("2006-01-02T15:04:05"):]
+	if strings.HasPrefix(rest, ".") {
+		b.WriteString(".")
+		for _, c := range rest[1:] {
+			if c < '0' || c > '9' {
+				break
+			}
+			b.WriteString("0")
+		}
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		b.WriteString("Z07:00")
+	} else {
+		b.WriteString("-07:00")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		json string
+		want time.Time
+	}{
+		"platform format": {
+			json: `"2021-05-25T04:29:11.898Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC),
+		},
+		"platform format trailing zeros": {
+			json: `"2021-05-25T04:29:11.900Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 900000000, time.UTC),
+		},
+		"platform format whole second": {
+			json: `"2021-05-25T04:29:11.000Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC),
+		},
+		"rfc3339 nano": {
+			json: `"2021-05-25T04:29:11.898123456Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 898123456, time.UTC),
+		},
+		"rfc3339 nano with offset": {
+			json: `"2021-05-25T05:29:11.898+01:00"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC),
+		},
+		"whole second": {
+			json: `"2021-05-25T04:29:11Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC),
+		},
+		"whole second with offset": {
+			json: `"2021-05-25T06:29:11+02:00"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC),
+		},
+		"trailing zeros with offset": {
+			json: `"2021-05-25T06:29:11.800+02:00"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 800000000, time.UTC),
+		},
+		"microseconds": {
+			json: `"2021-05-25T04:29:11.898100Z"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 898100000, time.UTC),
+		},
+		"zero offset": {
+			json: `"2021-05-25T04:29:11.898+00:00"`,
+			want: time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC),
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var ts Timestamp
+			require.NoError(t, json.Unmarshal([]byte(tc.json), &ts))
+			assert.True(t, tc.want.Equal(ts.Time), ts.Time)
+
+			got, err := json.Marshal(ts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.json, string(got))
+		})
+	}
+}
+
+func TestTimestampUnmarshalPlatformFormat(t *testing.T) {
+	var ts Timestamp
+	require.NoError(t, json.Unmarshal([]byte(`"2021-05-25T04:29:11.898Z"`), &ts))
+
+	assert.Equal(t, *NewTimestamp(time.Date(2021, 5, 25, 4, 29, 11, 898000000, time.UTC)), ts)
+}
+
+func TestTimestampMarshalFormats(t *testing.T) {
+	got, err := json.Marshal(NewTimestamp(time.Date(2021, 5, 25, 4, 29, 11, 0, time.UTC)))
+	require.NoError(t, err)
+	assert.Equal(t, `"2021-05-25T04:29:11.000Z"`, string(got))
+
+	got, err = json.Marshal(NewTimestamp(time.Date(2021, 5, 25, 4, 29, 11, 1500, time.UTC)))
+	require.NoError(t, err)
+	assert.Equal(t, `"2021-05-25T04:29:11.0000015Z"`, string(got))
+}
+
+func TestTimestampUnmarshalNull(t *testing.T) {
+	var v struct {
+		At *Timestamp `json:"at"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"at": null}`), &v))
+	assert.Nil(t, v.At)
+
+	var ts Timestamp
+	require.NoError(t, json.Unmarshal([]byte(`null`), &ts))
+	assert.True(t, ts.IsZero())
+}
+
+func TestTimestampUnmarshalMalformed(t *testing.T) {
+	for _, data := range []string{
+		`"2021-05-25"`,
+		`"2021-05-25 04:29:11.898"`,
+		`"2021-13-25T04:29:11.898Z"`,
+		`"yesterday"`,
+		`""`,
+		`1621916951`,
+		`true`,
+	} {
+		var ts Timestamp
+		assert.Error(t, json.Unmarshal([]byte(data), &ts), data)
+	}
+}